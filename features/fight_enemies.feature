@@ -5,12 +5,79 @@ Feature: Fight Enemies
 
   Scenario: Player fights a specific number of enemies
     Given the player has a level of 10
+    And no fights fail
     When the player fights 100 enemies
-    Then the average time per enemy defeated should be less than 10 milliseconds
+    Then the player should defeat all 100 enemies
+    And the average time per enemy defeated should be less than 10 milliseconds
+    And the average time per enemy should not regress by more than 25% against the baseline
+    And the p99 time per enemy defeated should be less than 15 milliseconds
+    And fighting should allocate at most 0 objects per enemy
     And all fight operations should complete without error
 
   Scenario: Player fights many enemies
     Given the player has a level of 50
+    And no fights fail
     When the player fights 1000 enemies
     Then the average time per enemy defeated should be less than 15 milliseconds
+    And the p99 time per enemy defeated should be less than 20 milliseconds
     And all fight operations should complete without error
+    And no enemies should remain on the battlefield
+
+  @soft-assertions
+  Scenario: Player fights enemies against a performance checklist
+    Given the player has a level of 20
+    And no fights fail
+    When the player fights 50 enemies
+    Then the performance checklist should hold:
+      | metric     | comparator | value | unit      |
+      | avg        | <          | 10    | ms        |
+      | p95        | <          | 15    | ms        |
+      | allocs/op  | <=         | 10    | allocs    |
+      | bytes/op   | <=         | 2     | KB        |
+      | error rate | <          | 1     | %         |
+      | throughput | >          | 100   | enemies/s |
+
+  Scenario: Player fights enemies while the engine stresses the CPU
+    Given the engine simulates CPU-bound work
+    And the player has a level of 10
+    And no fights fail
+    When the player fights 100 enemies
+    Then the average time per enemy defeated should be less than 200 µs
+    And fighting should allocate at most 0 objects per enemy
+    And all fight operations should complete without error
+
+  Scenario: Player fights many enemies in simulated time
+    Given the engine runs on a virtual clock
+    And the player has a level of 50
+    And the player wields a halberd
+    And no fights fail
+    When the player fights 1000 enemies
+    Then the average time per enemy defeated should be less than 2.1 µs
+    And the p99 time per enemy defeated should be less than 2.1 µs
+    And all fight operations should complete without error
+
+  Scenario: A player without a level is rejected cleanly
+    Given the player has a level of 0
+    When the player fights 10 enemies
+    Then all fight operations should fail with invalid profile errors
+
+  Scenario: Player fights enemies at Hulao Gate
+    Given the battlefield 'hulao_gate' is loaded
+    And the player has a level of 10
+    And no fights fail
+    When the player fights 100 enemies
+    Then the player should defeat all 100 enemies
+    And every enemy should charge in from a hostile base
+    And the average time per enemy defeated should be less than 10 milliseconds
+    And all fight operations should complete without error
+    And no enemies should remain on the battlefield
+
+  Scenario: Player fights enemies at Sishui Gate
+    Given the battlefield 'sishui_gate' is loaded
+    And the player has a level of 10
+    And no fights fail
+    When the player fights 50 enemies
+    Then the player should defeat all 50 enemies
+    And every enemy should charge in from a hostile base
+    And all fight operations should complete without error
+    And no enemies should remain on the battlefield
@@ -5,12 +5,60 @@ Feature: Hit Walls
 
   Scenario: Player character repeatedly hits a wall
     Given the player is moving at high speed
+    And the benchmark is repeated 5 times
+    And no wall hits fail
     When the player hits a wall 200 times
     Then the average impact processing time should be less than 5 milliseconds
+    And the p99 time per wall hit should be less than 10 milliseconds
+    And the p50 time per wall hit should be less than 2500µs
+    And hitting walls should allocate at most 1 object per wall hit
+    And the mean time per wall hit should be less than 5 milliseconds with 95% confidence
+    And the time per wall hit should not be significantly slower than the baseline
     And all hit wall operations should complete without error
 
   Scenario: Player character hits a wall once
     Given the player is moving at low speed
+    And no wall hits fail
     When the player hits a wall 1 time
     Then the average impact processing time should be less than 5 milliseconds
+    And every wall hit should be resolved outside the walls
+    And the player should bounce back
+    And all hit wall operations should complete without error
+
+  Scenario: Player character hits a wall while some impacts spike
+    Given the player is moving at high speed
+    And 1% of wall hits take an extra 5 ms
+    And no wall hits fail
+    When the player hits a wall 100 times
+    Then the average impact processing time should be less than 5 milliseconds
+    And the p95 time per wall hit should be less than 5 milliseconds
+    And every wall hit should be resolved outside the walls
+    And all hit wall operations should complete without error
+
+  Scenario: Every failing wall hit is counted
+    Given the player is moving at low speed
+    And 100% of wall hits fail
+    When the player hits a wall 1 time
+    Then the performance checklist should hold:
+      | metric     | comparator | value | unit |
+      | error rate | ==         | 100   | %    |
+
+  Scenario: Player character hits a wall at extreme speed without tunnelling
+    Given the player is moving at 600 units per second
+    And no wall hits fail
+    When the player hits a wall 100 times
+    Then the average impact processing time should be less than 5 milliseconds
+    And the p99 time per wall hit should be less than 10 milliseconds
+    And every wall hit should be resolved outside the walls
+    And the player should bounce back
+    And all hit wall operations should complete without error
+
+  Scenario: Player character hits the walls of Hulao Gate
+    Given the battlefield 'hulao_gate' is loaded
+    And the player is moving at high speed
+    And no wall hits fail
+    When the player hits a wall 90 times
+    Then the average impact processing time should be less than 5 milliseconds
+    And every wall hit should be resolved outside the walls
+    And the player should bounce back
     And all hit wall operations should complete without error
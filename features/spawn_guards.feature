@@ -5,12 +5,55 @@ Feature: Spawn Guards
 
   Scenario: Multiple guards spawn and player reacts
     Given the player is in the 'market_square' area
+    And no guard spawns fail
     When 50 guards spawn around the player
     Then the player reacts to all guards within 5 seconds
+    And the p99 time per guard spawned should be less than 10 milliseconds
+    And spawning guards should allocate at most 2 KB per guard spawned
     And all guard spawning operations should complete without error
+    And no guards should remain on the battlefield
 
   Scenario: A single guard spawns
     Given the player is in the 'castle_gate' area
+    And no guard spawns fail
     When 1 guard spawns near the player
     Then the player reacts to all guards within 1 second
+    And the average time per guard spawned should be less than 5.5 ms
     And all guard spawning operations should complete without error
+
+  Scenario: Guard spawning stays within its error budget
+    Given the player is in the 'market_square' area
+    And 0.1% of guard spawns fail
+    And every benchmark round runs 100 operations
+    When 50 guards spawn around the player
+    Then the guard spawning error rate should be below 15%
+
+  Scenario: Slow guard spawns time out instead of spawning anomalies
+    Given the player is in the 'castle_gate' area
+    And 5% of guard spawns fail
+    And failing guard spawns fail with 'timeout' errors
+    When 10 guards spawn around the player
+    Then no spawn anomaly errors should occur
+
+  Scenario: Guards cannot spawn in an unknown area
+    Given the player is in the 'peach_garden' area
+    When 5 guards spawn around the player
+    Then all guard spawning operations should fail with unknown area errors
+
+  Scenario: An area caps how many guards spawn at once
+    Given the player is in the 'castle_gate' area
+    When 21 guards spawn around the player
+    Then all guard spawning operations should fail with population cap errors
+
+  Scenario: Guards spawn at Hulao Gate
+    Given the battlefield 'hulao_gate' is loaded
+    And no guard spawns fail
+    When 50 guards spawn around the player
+    Then the player reacts to all guards within 5 seconds
+    And all guard spawning operations should complete without error
+    And no guards should remain on the battlefield
+
+  Scenario: Hulao Gate caps how many guards spawn at once
+    Given the battlefield 'hulao_gate' is loaded
+    When 61 guards spawn around the player
+    Then all guard spawning operations should fail with population cap errors
@@ -0,0 +1,203 @@
+package gameengine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Area is a part of the battlefield guards can be spawned in. Positions are world
+// coordinates and the area is centered on the world origin: the built-in areas surround
+// the default player start and the area of a level covers the whole level.
+type Area struct {
+	Name string
+	// Size is the width (X) and height (Y) of the area.
+	Size Vec2
+	// Obstacles are the walls and buildings guards cannot stand in.
+	Obstacles []AABB
+	// SpawnPoints are where guards appear; later guards of a call ring the earlier ones.
+	SpawnPoints []Vec2
+	// PopulationCap is the most guards a single call may spawn in the area.
+	PopulationCap int
+	// TerrainCost multiplies the work of spawning a guard; 1 is open ground.
+	TerrainCost float64
+}
+
+// DefaultArea is the area used when an operation names none.
+const DefaultArea = "open_field"
+
+// builtinAreas are registered in every new AreaRegistry.
+var builtinAreas = []Area{
+	{
+		Name:          DefaultArea,
+		Size:          Vec2{40, 40},
+		SpawnPoints:   []Vec2{{5, 0}, {0, 5}, {-5, 0}, {0, -5}},
+		PopulationCap: 500,
+		TerrainCost:   1,
+	},
+	{
+		Name: "market_square",
+		Size: Vec2{30, 30},
+		Obstacles: []AABB{
+			{Min: Vec2{-8, -8}, Max: Vec2{-4, -4}},
+			{Min: Vec2{4, 4}, Max: Vec2{8, 8}},
+		},
+		SpawnPoints:   []Vec2{{10, 0}, {-10, 0}, {0, 10}, {0, -10}, {6, -6}, {-6, 6}},
+		PopulationCap: 100,
+		TerrainCost:   1,
+	},
+	{
+		Name: "castle_gate",
+		Size: Vec2{20, 12},
+		Obstacles: []AABB{
+			{Min: Vec2{-10, 4}, Max: Vec2{-1.5, 6}},
+			{Min: Vec2{1.5, 4}, Max: Vec2{10, 6}},
+		},
+		SpawnPoints:   []Vec2{{0, 3}, {-3, 2}, {3, 2}},
+		PopulationCap: 20,
+		TerrainCost:   1.5,
+	},
+}
+
+// UnknownAreaError reports an area that is not registered. It wraps ErrUnknownArea.
+type UnknownAreaError struct {
+	Name string
+}
+
+func (e *UnknownAreaError) Error() string {
+	return fmt.Sprintf("unknown area '%s'", e.Name)
+}
+
+func (e *UnknownAreaError) Unwrap() error {
+	return ErrUnknownArea
+}
+
+// PopulationError reports a call spawning more guards than an area holds. It wraps ErrPopulationCap.
+type PopulationError struct {
+	Area      string
+	Requested int
+	Cap       int
+}
+
+func (e *PopulationError) Error() string {
+	return fmt.Sprintf("area '%s' holds at most %d guards, got %d", e.Area, e.Cap, e.Requested)
+}
+
+func (e *PopulationError) Unwrap() error {
+	return ErrPopulationCap
+}
+
+// AreaRegistry holds the areas known to a Simulator. It is safe for concurrent use.
+type AreaRegistry struct {
+	mu    sync.RWMutex
+	areas map[string]Area
+}
+
+// NewAreaRegistry returns a registry holding the built-in areas.
+func NewAreaRegistry() *AreaRegistry {
+	r := &AreaRegistry{areas: map[string]Area{}}
+	for _, area := range builtinAreas {
+		if err := r.Register(area); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register adds or replaces an area after validating it.
+func (r *AreaRegistry) Register(area Area) error {
+	if err := area.validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.areas[area.Name] = area
+	return nil
+}
+
+// Lookup returns the area with the given name, or an *UnknownAreaError.
+func (r *AreaRegistry) Lookup(name string) (Area, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	area, ok := r.areas[name]
+	if !ok {
+		return Area{}, &UnknownAreaError{Name: name}
+	}
+	return area, nil
+}
+
+// Names returns the names of every registered area, sorted.
+func (r *AreaRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.areas))
+	for name := range r.areas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithAreas replaces the area registry of the Simulator.
+func WithAreas(areas *AreaRegistry) Option {
+	return func(s *Simulator) {
+		s.areas = areas
+	}
+}
+
+// Areas returns the area registry of the Simulator.
+func (s *Simulator) Areas() *AreaRegistry {
+	return s.areas
+}
+
+// bounds returns the box covered by the area.
+func (a Area) bounds() AABB {
+	half := a.Size.Scale(0.5)
+	return AABB{Min: half.Scale(-1), Max: half}
+}
+
+func (a Area) validate() error {
+	switch {
+	case a.Name == "":
+		return fmt.Errorf("area needs a name")
+	case a.Size.X <= 0 || a.Size.Y <= 0:
+		return fmt.Errorf("area '%s' needs a positive size, got %v", a.Name, a.Size)
+	case len(a.SpawnPoints) == 0:
+		return fmt.Errorf("area '%s' needs at least one spawn point", a.Name)
+	case a.PopulationCap <= 0:
+		return fmt.Errorf("area '%s' needs a positive population cap, got %d", a.Name, a.PopulationCap)
+	case a.TerrainCost <= 0:
+		return fmt.Errorf("area '%s' needs a positive terrain cost, got %g", a.Name, a.TerrainCost)
+	}
+	bounds := a.bounds()
+	for _, p := range a.SpawnPoints {
+		if p.X < bounds.Min.X || p.X > bounds.Max.X || p.Y < bounds.Min.Y || p.Y > bounds.Max.Y {
+			return fmt.Errorf("spawn point %v lies outside area '%s'", p, a.Name)
+		}
+		for _, obstacle := range a.Obstacles {
+			if _, inside := CircleAABB(Circle{Center: p, Radius: guardRadius}, obstacle); inside {
+				return fmt.Errorf("spawn point %v of area '%s' lies inside an obstacle", p, a.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// guardRadius is the radius of a guard's collision circle.
+const guardRadius = 0.4
+
+// spawnPosition returns where the i-th guard of a call appears: around the spawn points in
+// rings growing with every round over them, kept inside the area and pushed out of obstacles.
+func (a Area) spawnPosition(i int) Vec2 {
+	point := a.SpawnPoints[i%len(a.SpawnPoints)]
+	ring := i / len(a.SpawnPoints)
+	pos := point
+	if ring > 0 {
+		angle := float64(ring) * 2.399963 // the golden angle spreads the rings evenly
+		pos = point.Add(Vec2{math.Cos(angle), math.Sin(angle)}.Scale(2 * guardRadius * float64(ring)))
+	}
+	bounds := a.bounds()
+	pos = bounds.expand(-guardRadius).closestPoint(pos)
+	return pushOut(pos, guardRadius, a.Obstacles)
+}
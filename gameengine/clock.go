@@ -0,0 +1,81 @@
+package gameengine
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the Simulator.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the wall clock. Simulated work really takes its time on it.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// VirtualClock is a Clock that only moves when simulated work advances it. On a
+// VirtualClock the Simulator does no real work, so a scenario fighting a thousand
+// enemies finishes in microseconds while its Results still report the modeled time.
+type VirtualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewVirtualClock returns a VirtualClock starting at start.
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the current simulated time.
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the simulated time forward by d.
+func (c *VirtualClock) Advance(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// WithClock sets the time source of the Simulator. The default is the wall clock;
+// with a *VirtualClock simulated work advances the clock instead of using the WorkModel.
+func WithClock(clock Clock) Option {
+	return func(s *Simulator) {
+		s.clock = clock
+	}
+}
+
+// EntityObserver is told how long a single entity (enemy, guard, hit) of an operation took.
+type EntityObserver func(op Operation, took time.Duration)
+
+// WithEntityObserver reports the time of every entity an operation processes to observe,
+// measured on the Simulator's clock. Entities that fail are not reported. A nil observer
+// switches reporting off.
+func WithEntityObserver(observe EntityObserver) Option {
+	return func(s *Simulator) {
+		s.observe = observe
+	}
+}
+
+// Clock returns the time source of the Simulator.
+func (s *Simulator) Clock() Clock {
+	return s.clock
+}
+
+// spend simulates d of work: on a VirtualClock it advances the clock, otherwise the
+// WorkModel takes real time.
+func (s *Simulator) spend(d time.Duration) {
+	if virtual, ok := s.clock.(*VirtualClock); ok {
+		virtual.Advance(d)
+		return
+	}
+	s.work.Do(d)
+}
@@ -0,0 +1,196 @@
+package gameengine
+
+import "math"
+
+// AABB is an axis-aligned box, used for walls and other static obstacles.
+type AABB struct {
+	Min, Max Vec2
+}
+
+// Circle is the collision shape of a character standing still.
+type Circle struct {
+	Center Vec2
+	Radius float64
+}
+
+// Capsule is a circle swept along a segment, the collision shape of a character moving
+// from A to B during one step.
+type Capsule struct {
+	A, B   Vec2
+	Radius float64
+}
+
+// Contact describes where a moving character touched an obstacle.
+type Contact struct {
+	// Point is the point of the obstacle that was touched.
+	Point Vec2
+	// Normal is the unit surface normal at Point, pointing away from the obstacle.
+	Normal Vec2
+	// Time is the fraction (0 to 1) of the movement done when the contact happened.
+	Time float64
+	// Depth is how far the character already overlapped the obstacle, for contacts at Time 0.
+	Depth float64
+	// Resolved is where the character's center ends up once the contact is resolved.
+	Resolved Vec2
+}
+
+// contactSkin keeps resolved positions a hair away from the surface they touched.
+const contactSkin = 1e-3
+
+// closestPoint returns the point of the box closest to p.
+func (b AABB) closestPoint(p Vec2) Vec2 {
+	return Vec2{math.Max(b.Min.X, math.Min(p.X, b.Max.X)), math.Max(b.Min.Y, math.Min(p.Y, b.Max.Y))}
+}
+
+// expand returns the box grown by r on every side.
+func (b AABB) expand(r float64) AABB {
+	return AABB{Min: Vec2{b.Min.X - r, b.Min.Y - r}, Max: Vec2{b.Max.X + r, b.Max.Y + r}}
+}
+
+// CircleAABB reports whether a circle overlaps a box, and the contact pushing it out.
+func CircleAABB(c Circle, box AABB) (Contact, bool) {
+	closest := box.closestPoint(c.Center)
+	offset := c.Center.Sub(closest)
+	dist := offset.Len()
+	if dist >= c.Radius {
+		return Contact{}, false
+	}
+	if dist > 0 {
+		normal := offset.Scale(1 / dist)
+		depth := c.Radius - dist
+		return Contact{Point: closest, Normal: normal, Depth: depth, Resolved: c.Center.Add(normal.Scale(depth + contactSkin))}, true
+	}
+
+	// The center is inside the box: push it out through the nearest face.
+	faces := [4]struct {
+		dist   float64
+		normal Vec2
+	}{
+		{c.Center.X - box.Min.X, Vec2{-1, 0}},
+		{box.Max.X - c.Center.X, Vec2{1, 0}},
+		{c.Center.Y - box.Min.Y, Vec2{0, -1}},
+		{box.Max.Y - c.Center.Y, Vec2{0, 1}},
+	}
+	nearest := faces[0]
+	for _, f := range faces[1:] {
+		if f.dist < nearest.dist {
+			nearest = f
+		}
+	}
+	depth := nearest.dist + c.Radius
+	point := c.Center.Add(nearest.normal.Scale(nearest.dist))
+	return Contact{Point: point, Normal: nearest.normal, Depth: depth, Resolved: c.Center.Add(nearest.normal.Scale(depth + contactSkin))}, true
+}
+
+// SweepCircleAABB moves a circle by move and returns the first contact with the box.
+// Unlike testing the end position, a sweep cannot tunnel through thin walls however
+// fast the circle moves. A circle that already overlaps the box touches it at Time 0,
+// unless it is moving away from it, so it can always get out.
+func SweepCircleAABB(c Circle, move Vec2, box AABB) (Contact, bool) {
+	if contact, overlapping := CircleAABB(c, box); overlapping {
+		if move.Dot(contact.Normal) > 0 {
+			return Contact{}, false
+		}
+		return contact, true
+	}
+
+	// Slab test of the center's ray against the box grown by the radius.
+	grown := box.expand(c.Radius)
+	tEnter, tExit := 0.0, 1.0
+	var normal Vec2
+	for axis := 0; axis < 2; axis++ {
+		origin, delta, lo, hi := c.Center.X, move.X, grown.Min.X, grown.Max.X
+		axisNormal := Vec2{-1, 0}
+		if axis == 1 {
+			origin, delta, lo, hi = c.Center.Y, move.Y, grown.Min.Y, grown.Max.Y
+			axisNormal = Vec2{0, -1}
+		}
+		if delta == 0 {
+			// Sliding along a face at exactly the radius only touches it, like CircleAABB.
+			if origin <= lo || origin >= hi {
+				return Contact{}, false
+			}
+			continue
+		}
+		t1, t2 := (lo-origin)/delta, (hi-origin)/delta
+		n := axisNormal
+		if t1 > t2 {
+			t1, t2 = t2, t1
+			n = n.Scale(-1)
+		}
+		// A circle already touching the face enters it at t1 == 0, which still needs its normal.
+		if t1 >= tEnter {
+			tEnter, normal = t1, n
+		}
+		if t2 < tExit {
+			tExit = t2
+		}
+		// Entering and leaving at the same time only touches the box, like CircleAABB.
+		if tEnter >= tExit {
+			return Contact{}, false
+		}
+	}
+
+	center := c.Center.Add(move.Scale(tEnter))
+	inX := center.X >= box.Min.X && center.X <= box.Max.X
+	inY := center.Y >= box.Min.Y && center.Y <= box.Max.Y
+	if !inX && !inY {
+		// The grown box has square corners but the real swept shape is rounded there:
+		// the circle only touches the box if its center path comes within Radius of the corner.
+		corner := box.closestPoint(center)
+		t, ok := sweepPointCircle(c.Center, move, Circle{Center: corner, Radius: c.Radius})
+		if !ok {
+			return Contact{}, false
+		}
+		tEnter = t
+		center = c.Center.Add(move.Scale(t))
+		normal = center.Sub(corner).Scale(1 / c.Radius)
+	}
+
+	contact := Contact{Normal: normal, Time: tEnter}
+	contact.Point = center.Sub(normal.Scale(c.Radius))
+	contact.Resolved = center.Add(normal.Scale(contactSkin))
+	return contact, true
+}
+
+// sweepPointCircle returns the first time (0 to 1) at which p + t*move enters the circle.
+func sweepPointCircle(p, move Vec2, c Circle) (float64, bool) {
+	m := p.Sub(c.Center)
+	a := move.Dot(move)
+	b := m.Dot(move)
+	k := m.Dot(m) - c.Radius*c.Radius
+	if a == 0 {
+		return 0, k <= 0
+	}
+	disc := b*b - a*k
+	if disc <= 0 {
+		return 0, false
+	}
+	t := (-b - math.Sqrt(disc)) / a
+	if t < 0 || t > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+// pushOut moves a circle at pos with the given radius out of every box it overlaps.
+// Pushing it out of one box can push it into the next, so it repeats until it is clear.
+func pushOut(pos Vec2, radius float64, boxes []AABB) Vec2 {
+	for pass := 0; pass < len(boxes); pass++ {
+		clear := true
+		for _, box := range boxes {
+			if contact, inside := CircleAABB(Circle{Center: pos, Radius: radius}, box); inside {
+				pos, clear = contact.Resolved, false
+			}
+		}
+		if clear {
+			break
+		}
+	}
+	return pos
+}
+
+// CapsuleAABB reports whether a capsule touches a box, and where along it (from A to B) it does first.
+func CapsuleAABB(capsule Capsule, box AABB) (Contact, bool) {
+	return SweepCircleAABB(Circle{Center: capsule.A, Radius: capsule.Radius}, capsule.B.Sub(capsule.A), box)
+}
@@ -0,0 +1,135 @@
+package gameengine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSweepCircleAABB(t *testing.T) {
+	thinWall := AABB{Min: Vec2{0, -1}, Max: Vec2{0.2, 1}}
+	square := AABB{Min: Vec2{0, 0}, Max: Vec2{1, 1}}
+	diagonal := Vec2{-1, -1}.Scale(1 / math.Sqrt2)
+
+	tests := []struct {
+		name   string
+		circle Circle
+		move   Vec2
+		box    AABB
+		hit    bool
+		time   float64
+		normal Vec2
+		depth  float64
+	}{
+		{
+			name:   "a fast move does not tunnel through a thin wall",
+			circle: Circle{Center: Vec2{-2, 0}, Radius: 0.5},
+			move:   Vec2{100, 0},
+			box:    thinWall,
+			hit:    true,
+			time:   0.015,
+			normal: Vec2{-1, 0},
+		},
+		{
+			name:   "a move stopping short of the wall misses it",
+			circle: Circle{Center: Vec2{-2, 0}, Radius: 0.5},
+			move:   Vec2{1, 0},
+			box:    thinWall,
+		},
+		{
+			name:   "sliding along a face at exactly the radius only touches it",
+			circle: Circle{Center: Vec2{-0.5, -3}, Radius: 0.5},
+			move:   Vec2{0, 6},
+			box:    thinWall,
+		},
+		{
+			name:   "a diagonal move hits the rounded corner",
+			circle: Circle{Center: Vec2{-1, -1}, Radius: 0.5},
+			move:   Vec2{2, 2},
+			box:    square,
+			hit:    true,
+			time:   (1 - 0.5/math.Sqrt2) / 2,
+			normal: diagonal,
+		},
+		{
+			name:   "a move through the grown corner but clear of the rounded corner misses",
+			circle: Circle{Center: Vec2{-1.45, 0.55}, Radius: 0.5},
+			move:   Vec2{2, -2},
+			box:    square,
+		},
+		{
+			name:   "touching, then moving in touches at once",
+			circle: Circle{Center: Vec2{-0.5, 0.5}, Radius: 0.5},
+			move:   Vec2{1, 0},
+			box:    square,
+			hit:    true,
+			normal: Vec2{-1, 0},
+		},
+		{
+			name:   "touching, then moving away is free",
+			circle: Circle{Center: Vec2{-0.5, 0.5}, Radius: 0.5},
+			move:   Vec2{-1, 0},
+			box:    square,
+		},
+		{
+			name:   "touching a corner, then moving in touches at once",
+			circle: Circle{Center: diagonal.Scale(0.5), Radius: 0.5},
+			move:   Vec2{1, 1},
+			box:    square,
+			hit:    true,
+			normal: diagonal,
+		},
+		{
+			name:   "moving into an overlap touches at once",
+			circle: Circle{Center: Vec2{-0.3, 0.5}, Radius: 0.5},
+			move:   Vec2{1, 0},
+			box:    square,
+			hit:    true,
+			normal: Vec2{-1, 0},
+			depth:  0.2,
+		},
+		{
+			name:   "standing in an overlap touches at once",
+			circle: Circle{Center: Vec2{-0.3, 0.5}, Radius: 0.5},
+			box:    square,
+			hit:    true,
+			normal: Vec2{-1, 0},
+			depth:  0.2,
+		},
+		{
+			name:   "moving away from an overlap is free",
+			circle: Circle{Center: Vec2{-0.3, 0.5}, Radius: 0.5},
+			move:   Vec2{-1, 0},
+			box:    square,
+		},
+		{
+			name:   "moving away from an overlap at an angle is free",
+			circle: Circle{Center: Vec2{-0.3, 0.5}, Radius: 0.5},
+			move:   Vec2{-1, 3},
+			box:    square,
+		},
+	}
+	const epsilon = 1e-9
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contact, hit := SweepCircleAABB(tt.circle, tt.move, tt.box)
+			if hit != tt.hit {
+				t.Fatalf("hit = %v, want %v (contact %+v)", hit, tt.hit, contact)
+			}
+			if !hit {
+				return
+			}
+			if math.Abs(contact.Time-tt.time) > epsilon {
+				t.Errorf("time = %v, want %v", contact.Time, tt.time)
+			}
+			if contact.Normal.Sub(tt.normal).Len() > epsilon {
+				t.Errorf("normal = %v, want %v", contact.Normal, tt.normal)
+			}
+			if math.Abs(contact.Depth-tt.depth) > epsilon {
+				t.Errorf("depth = %v, want %v", contact.Depth, tt.depth)
+			}
+			if _, inside := CircleAABB(Circle{Center: contact.Resolved, Radius: tt.circle.Radius}, tt.box); inside {
+				t.Errorf("resolved position %v still overlaps the box", contact.Resolved)
+			}
+		})
+	}
+}
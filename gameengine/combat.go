@@ -0,0 +1,96 @@
+package gameengine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPlayerDefeated is returned by FightEnemies when the player is knocked out before
+// every enemy has fallen.
+var ErrPlayerDefeated = errors.New("the player was defeated")
+
+// CombatResult sums up the fighting done by one FightEnemies call.
+type CombatResult struct {
+	// KOs is the number of enemies knocked out by the player.
+	KOs int
+	// Attacks is the number of blows the player struck.
+	Attacks int
+	// CriticalHits is how many of the player's blows were critical.
+	CriticalHits int
+	// DamageDealt is the damage done to enemies.
+	DamageDealt int
+	// DamageTaken is the damage the player received.
+	DamageTaken int
+	// FromBases is how many enemies charged in from the bases of the loaded battlefield.
+	FromBases int
+}
+
+// Combat tuning. A critical hit deals critMultiplier times the damage; its chance
+// grows with the player's level up to maxCritChance.
+const (
+	baseCritChance     = 0.05
+	critChancePerLevel = 0.005
+	maxCritChance      = 0.5
+	critMultiplier     = 1.5
+)
+
+// damage is the health an attack takes from a defender. Defense softens blows
+// rather than negating them, and every blow does at least 1 damage.
+func damage(attack, defense int) int {
+	if attack+defense <= 0 {
+		return 1
+	}
+	if d := attack * attack / (attack + defense); d > 1 {
+		return d
+	}
+	return 1
+}
+
+// critChance is the chance of a player's blow at the given level being critical.
+func critChance(level int) float64 {
+	chance := baseCritChance + critChancePerLevel*float64(level)
+	if chance > maxCritChance {
+		return maxCritChance
+	}
+	return chance
+}
+
+// duel lets the player and the enemy at index i trade blows until one of them falls.
+// The player strikes first. The enemy leaves the battlefield either way, so a call that
+// stops early does not leave it behind for the next one. It returns ErrPlayerDefeated if
+// the player is knocked out.
+func (s *Simulator) duel(w *World, i int, result *CombatResult) error {
+	enemy := &w.enemies[i]
+	player := &w.player
+	for enemy.Alive() {
+		hit := damage(player.Attack, enemy.Defense)
+		if s.combatRng.Float64() < critChance(player.Level) {
+			hit = int(float64(hit) * critMultiplier)
+			result.CriticalHits++
+		}
+		if hit > enemy.Health {
+			hit = enemy.Health
+		}
+		enemy.Health -= hit
+		result.Attacks++
+		result.DamageDealt += hit
+		if !enemy.Alive() {
+			break
+		}
+
+		taken := damage(enemy.Attack, player.Defense)
+		if taken > player.Health {
+			taken = player.Health
+		}
+		player.Health -= taken
+		result.DamageTaken += taken
+		if !player.Alive() {
+			id := enemy.ID
+			w.removeEnemy(i)
+			return fmt.Errorf("%w by enemy %d", ErrPlayerDefeated, id)
+		}
+	}
+	w.removeEnemy(i)
+	result.KOs++
+	return nil
+}
@@ -1,13 +1,115 @@
 package gameengine
 
 import (
-	"fmt"
-	"math/rand"
-	"testing"
+	"context"
 	"time"
 )
 
-// SimulateWork simulates some CPU-bound work.
+// Operation names one of the simulated engine workloads.
+type Operation string
+
+const (
+	// OperationFight is the workload behind FightEnemies.
+	OperationFight Operation = "fight"
+	// OperationSpawnGuards is the workload behind SpawnGuards.
+	OperationSpawnGuards Operation = "guard spawning"
+	// OperationHitWall is the workload behind HitWall.
+	OperationHitWall Operation = "hit wall"
+)
+
+// FightOptions configures a single FightEnemies call.
+type FightOptions struct {
+	// Enemies is the number of enemies fought in this call.
+	Enemies int
+	// Player is the fighting player; its level makes fights faster and the player stronger.
+	Player PlayerProfile
+}
+
+// SpawnOptions configures a single SpawnGuards call.
+type SpawnOptions struct {
+	// Guards is the number of guards spawned in this call.
+	Guards int
+	// Area is the name of the area the guards spawn in. Empty means DefaultArea.
+	Area string
+}
+
+// HitWallOptions configures a single HitWall call.
+type HitWallOptions struct {
+	// Hits is the number of wall impacts processed in this call.
+	Hits int
+	// Speed is how fast the player charges at the wall, in world units per second.
+	// Zero means unset and runs at DefaultSpeed; any other speed must pass ValidateSpeed.
+	Speed float64
+}
+
+// Result describes what a single engine call did.
+type Result struct {
+	Operation Operation
+	// Count is the number of entities (enemies, guards, hits) processed.
+	Count int
+	// Elapsed is the time the call took on the Simulator's Clock.
+	Elapsed time.Duration
+	// Combat is the outcome of a FightEnemies call.
+	Combat CombatResult
+	// Impacts are the wall impacts of a HitWall call, in order.
+	Impacts []Impact
+	// Steps is the number of collision steps swept by a HitWall call, knockbacks included.
+	// Slow charges take many ticks to reach the wall, fast ones need several sub-steps per tick.
+	Steps int
+}
+
+// PerItem returns the average time spent per processed entity.
+func (r Result) PerItem() time.Duration {
+	if r.Count == 0 {
+		return 0
+	}
+	return r.Elapsed / time.Duration(r.Count)
+}
+
+// Engine runs the simulated game workloads. Implementations must not depend on
+// the testing package so the same workloads can be driven by benchmarks, CLIs or
+// load generators alike.
+type Engine interface {
+	FightEnemies(ctx context.Context, opts FightOptions) (Result, error)
+	SpawnGuards(ctx context.Context, opts SpawnOptions) (Result, error)
+	HitWall(ctx context.Context, opts HitWallOptions) (Result, error)
+}
+
+// Simulator is the default Engine. Its operations create, fight and dismiss
+// the entities of a World and simulate a fixed amount of work per entity.
+type Simulator struct {
+	rng       *lockedRand
+	combatRng *lockedRand
+	faults    map[Operation]FaultModel
+	work      WorkModel
+	clock     Clock
+	world     *World
+	areas     *AreaRegistry
+	observe   EntityObserver
+}
+
+// NewSimulator returns a ready to use Simulator. Without WithSeed or WithRandSource
+// its random failures are seeded from the current time; without WithFaults every
+// operation fails once in a thousand calls; without WithWorkModel work is simulated by sleeping;
+// without WithClock it runs on the wall clock; without WithAreas it knows the built-in areas.
+func NewSimulator(opts ...Option) *Simulator {
+	s := &Simulator{rng: newTimeSeededRand(), combatRng: newTimeSeededRand(), faults: map[Operation]FaultModel{}, work: WorkSleep, clock: realClock{}, world: newWorld(), areas: NewAreaRegistry()}
+	for op, model := range defaultFaults {
+		s.faults[op] = model
+	}
+	s.Apply(opts...)
+	return s
+}
+
+var _ Engine = (*Simulator)(nil)
+
+// World returns the game state the Simulator's operations work on.
+func (s *Simulator) World() *World {
+	return s.world
+}
+
+// SimulateWork simulates some CPU-bound work. It is what WorkSleep does; pick
+// another WorkModel to actually load the CPU or memory.
 func SimulateWork(duration time.Duration) {
 	// This is a placeholder. In a real scenario, this would be actual game logic.
 	// For now, we'll just sleep to simulate work.
@@ -19,70 +121,141 @@ func SimulateWork(duration time.Duration) {
 	time.Sleep(duration)
 }
 
-// FightEnemies simulates the player fighting a number of enemies.
-// The function is designed to be called within a benchmark loop (b.N iterations).
-func FightEnemies(b *testing.B, numEnemiesPerIteration int, playerLevel int) error {
-	if numEnemiesPerIteration <= 0 {
-		return fmt.Errorf("numEnemiesPerIteration must be positive, got %d", numEnemiesPerIteration)
+// simulate runs step and work once per entity, stopping early if ctx is done, step fails
+// or an injected fault fires.
+func (s *Simulator) simulate(ctx context.Context, op Operation, count int, work time.Duration, step func(i int) error) (Result, error) {
+	faults := s.faults[op]
+	result := Result{Operation: op}
+	start := s.clock.Now()
+	for i := 0; i < count; i++ {
+		if err := ctx.Err(); err != nil {
+			result.Elapsed = s.clock.Now().Sub(start)
+			return result, err
+		}
+		var entityStart time.Time
+		if s.observe != nil {
+			entityStart = s.clock.Now()
+		}
+		if err := step(i); err != nil {
+			result.Elapsed = s.clock.Now().Sub(start)
+			return result, err
+		}
+		s.spend(work)
+		if s.roll(faults.SpikeRate) {
+			s.spend(faults.SpikeLatency)
+		}
+		if faults.Scope == PerEntity && s.roll(faults.FailureRate) {
+			result.Elapsed = s.clock.Now().Sub(start)
+			return result, faults.fail(op, i, count)
+		}
+		if s.observe != nil {
+			s.observe(op, s.clock.Now().Sub(entityStart))
+		}
+		result.Count++
 	}
-	// Simulate complexity based on playerLevel. Higher level = faster processing (less time per enemy).
-	// This is an arbitrary calculation for demonstration.
-	workPerEnemy := time.Microsecond * 100 / time.Duration(playerLevel)
-	
-	totalEnemiesInBenchmark := b.N * numEnemiesPerIteration
-
-	if b.N == 1 && numEnemiesPerIteration > 1 { // Special handling if benchmark runs once but for many items
-		// This logic helps if a Gherkin step says "fights 100 enemies" and we want that to be one benchmark op
-		// but still simulate work for 100 enemies.
-		// However, standard benchmark practice is b.N operations.
-		// For this placeholder, we'll assume b.N is the number of times the "fight N enemies" action is performed.
+	result.Elapsed = s.clock.Now().Sub(start)
+	if faults.Scope == PerBatch && s.roll(faults.FailureRate) {
+		return result, faults.fail(op, -1, count)
 	}
+	return result, nil
+}
 
-	// Simulate fighting each enemy for one benchmark iteration (b.N is 1 for this inner loop)
-	for i := 0; i < numEnemiesPerIteration; i++ {
-		SimulateWork(workPerEnemy) 
+// FightEnemies simulates the player fighting a number of enemies. On a loaded battlefield
+// they charge in from the bases of the other factions. Result.Combat tells how the fights
+// went; if the player is knocked out the call stops with ErrPlayerDefeated.
+func (s *Simulator) FightEnemies(ctx context.Context, opts FightOptions) (Result, error) {
+	if opts.Enemies <= 0 {
+		return Result{Operation: OperationFight}, &CountError{Operation: OperationFight, Count: opts.Enemies}
 	}
-	// In a real game, you might return an error if something went wrong during the fight.
-	if rand.Intn(1000) == 7 { // Simulate a rare random error
-		return fmt.Errorf("a mystical force interrupted the battle after %d enemies in one iteration", numEnemiesPerIteration)
+	if err := opts.Player.Validate(); err != nil {
+		return Result{Operation: OperationFight}, err
 	}
-	b.Logf("Simulated fighting %d enemies (player level %d). Total in benchmark: %d", numEnemiesPerIteration, playerLevel, totalEnemiesInBenchmark)
-	return nil
+	// Simulate complexity based on playerLevel. Higher level = faster processing (less time per enemy).
+	// This is an arbitrary calculation for demonstration.
+	workPerEnemy := time.Microsecond * 100 / time.Duration(opts.Player.Level)
+
+	w := s.world
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.applyProfile(opts.Player)
+	// Enemies charge in one by one and the player duels each of them.
+	var combat CombatResult
+	result, err := s.simulate(ctx, OperationFight, opts.Enemies, workPerEnemy, func(i int) error {
+		pos, faction, fromBase := w.enemyStart(i, opts.Enemies)
+		if fromBase {
+			combat.FromBases++
+		}
+		return s.duel(w, w.spawnEnemy(pos, faction), &combat)
+	})
+	result.Combat = combat
+	return result, err
 }
 
-// SpawnGuards simulates spawning a number of guards.
-// The function is designed to be called within a benchmark loop (b.N iterations).
-func SpawnGuards(b *testing.B, numGuardsPerIteration int) error {
-	if numGuardsPerIteration <= 0 {
-		return fmt.Errorf("numGuardsPerIteration must be positive, got %d", numGuardsPerIteration)
+// SpawnGuards simulates spawning a number of guards at the spawn points of an area. It
+// fails with an *UnknownAreaError for unregistered areas and a *PopulationError if the
+// area cannot hold that many guards.
+func (s *Simulator) SpawnGuards(ctx context.Context, opts SpawnOptions) (Result, error) {
+	if opts.Guards <= 0 {
+		return Result{Operation: OperationSpawnGuards}, &CountError{Operation: OperationSpawnGuards, Count: opts.Guards}
 	}
-	// Simulate work for spawning each guard.
-	workPerGuard := time.Microsecond * 50
-	for i := 0; i < numGuardsPerIteration; i++ {
-		SimulateWork(workPerGuard)
+	areaName := opts.Area
+	if areaName == "" {
+		areaName = DefaultArea
 	}
-	// In a real game, this might involve AI initialization, pathfinding calculations, etc.
-	if rand.Intn(1000) == 7 { // Simulate a rare random error
-		return fmt.Errorf("a magical anomaly prevented %d guards from spawning correctly in one iteration", numGuardsPerIteration)
+	area, err := s.areas.Lookup(areaName)
+	if err != nil {
+		return Result{Operation: OperationSpawnGuards}, err
+	}
+	if opts.Guards > area.PopulationCap {
+		return Result{Operation: OperationSpawnGuards}, &PopulationError{Area: area.Name, Requested: opts.Guards, Cap: area.PopulationCap}
 	}
-	b.Logf("Simulated spawning %d guards. Total in benchmark: %d", numGuardsPerIteration, b.N*numGuardsPerIteration)
-	return nil
+	// Simulate work for spawning each guard.
+	// In a real game, this might involve AI initialization, pathfinding calculations, etc.
+	// Rough terrain makes every guard more expensive to place.
+	workPerGuard := time.Duration(float64(time.Microsecond*50) * area.TerrainCost)
+
+	w := s.world
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// The guards of a call are dismissed when it returns, so every call starts from the same world.
+	defer w.dismissGuards()
+	return s.simulate(ctx, OperationSpawnGuards, opts.Guards, workPerGuard, func(i int) error {
+		w.spawnGuard(area.spawnPosition(i))
+		return nil
+	})
 }
 
-// HitWall simulates the player character hitting a wall.
-// The function is designed to be called within a benchmark loop (b.N iterations).
-func HitWall(b *testing.B, numHitsPerIteration int) error {
-	if numHitsPerIteration <= 0 {
-		return fmt.Errorf("numHitsPerIteration must be positive, got %d", numHitsPerIteration)
+// HitWall simulates the player character charging at a wall, sweeping its collision
+// circle against the walls of the battlefield and bouncing off them. Result.Impacts holds
+// the contact points, resolved positions and physics response of the hits.
+func (s *Simulator) HitWall(ctx context.Context, opts HitWallOptions) (Result, error) {
+	if opts.Hits <= 0 {
+		return Result{Operation: OperationHitWall}, &CountError{Operation: OperationHitWall, Count: opts.Hits}
 	}
-	// Simulate work for processing a wall hit (collision detection, physics response).
-	workPerHit := time.Microsecond * 20
-	for i := 0; i < numHitsPerIteration; i++ {
-		SimulateWork(workPerHit)
+	speed := opts.Speed
+	if speed == 0 {
+		speed = DefaultSpeed
 	}
-	if rand.Intn(1000) == 7 { // Simulate a rare random error
-		return fmt.Errorf("the wall phased out of existence during collision for %d hits in one iteration", numHitsPerIteration)
+	if err := ValidateSpeed(speed); err != nil {
+		return Result{Operation: OperationHitWall}, err
 	}
-	b.Logf("Simulated hitting a wall %d times. Total in benchmark: %d", numHitsPerIteration, b.N*numHitsPerIteration)
-	return nil
+	// Simulate work for processing a wall hit (physics response) on top of the collision detection.
+	workPerHit := time.Microsecond * 20
+
+	w := s.world
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	impacts := make([]Impact, 0, opts.Hits)
+	steps := 0
+	result, err := s.simulate(ctx, OperationHitWall, opts.Hits, workPerHit, func(i int) error {
+		impact, n, ok := w.hitWall(i, speed)
+		steps += n
+		if ok {
+			impacts = append(impacts, impact)
+		}
+		return nil
+	})
+	result.Impacts = impacts
+	result.Steps = steps
+	return result, err
 }
@@ -0,0 +1,64 @@
+package gameengine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned (wrapped) by the engine. Match them with errors.Is, or use
+// errors.As with *CountError and *InjectedFault to get the operation and entity.
+var (
+	// ErrInvalidCount is returned when an operation is asked to process no entities.
+	ErrInvalidCount = errors.New("invalid count")
+	// ErrInvalidProfile is returned when an operation is given an invalid PlayerProfile.
+	ErrInvalidProfile = errors.New("invalid player profile")
+	// ErrInvalidSpeed is returned when an operation is given a speed it cannot simulate.
+	ErrInvalidSpeed = errors.New("invalid speed")
+	// ErrUnknownArea is returned when an operation names an area that is not registered.
+	ErrUnknownArea = errors.New("unknown area")
+	// ErrPopulationCap is returned when a call would spawn more guards than its area holds.
+	ErrPopulationCap = errors.New("population cap exceeded")
+	// ErrInvalidLevel is returned when a level cannot be parsed or loaded.
+	ErrInvalidLevel = errors.New("invalid level")
+	// ErrBattleInterrupted is the error of FaultBattleInterrupted faults.
+	ErrBattleInterrupted = errors.New(faultMessages[FaultBattleInterrupted])
+	// ErrSpawnAnomaly is the error of FaultSpawnAnomaly faults.
+	ErrSpawnAnomaly = errors.New(faultMessages[FaultSpawnAnomaly])
+	// ErrWallPhased is the error of FaultWallPhased faults.
+	ErrWallPhased = errors.New(faultMessages[FaultWallPhased])
+	// ErrTimeout is the error of FaultTimeout faults.
+	ErrTimeout = errors.New(faultMessages[FaultTimeout])
+)
+
+var faultErrors = map[FaultKind]error{
+	FaultBattleInterrupted: ErrBattleInterrupted,
+	FaultSpawnAnomaly:      ErrSpawnAnomaly,
+	FaultWallPhased:        ErrWallPhased,
+	FaultTimeout:           ErrTimeout,
+}
+
+// countNouns names the entities of an operation in CountError messages.
+var countNouns = map[Operation]string{
+	OperationFight:       "enemies",
+	OperationSpawnGuards: "guards",
+	OperationHitWall:     "hits",
+}
+
+// CountError reports an operation called with a count that is not positive. It wraps ErrInvalidCount.
+type CountError struct {
+	Operation Operation
+	Count     int
+}
+
+func (e *CountError) Error() string {
+	return fmt.Sprintf("number of %s must be positive, got %d", countNouns[e.Operation], e.Count)
+}
+
+func (e *CountError) Unwrap() error {
+	return ErrInvalidCount
+}
+
+// Unwrap returns the sentinel error of the fault's kind, e.g. ErrSpawnAnomaly.
+func (f *InjectedFault) Unwrap() error {
+	return faultErrors[f.Kind]
+}
@@ -0,0 +1,112 @@
+package gameengine
+
+import (
+	"fmt"
+	"time"
+)
+
+// FaultScope decides how often an injected failure is rolled.
+type FaultScope int
+
+const (
+	// PerBatch rolls once per engine call.
+	PerBatch FaultScope = iota
+	// PerEntity rolls once per enemy, guard or hit; the first failing entity aborts the call.
+	PerEntity
+)
+
+// FaultKind classifies an injected failure.
+type FaultKind string
+
+const (
+	FaultBattleInterrupted FaultKind = "battle interrupted"
+	FaultSpawnAnomaly      FaultKind = "spawn anomaly"
+	FaultWallPhased        FaultKind = "wall phased"
+	FaultTimeout           FaultKind = "timeout"
+)
+
+var faultMessages = map[FaultKind]string{
+	FaultBattleInterrupted: "a mystical force interrupted the battle",
+	FaultSpawnAnomaly:      "a magical anomaly prevented guards from spawning correctly",
+	FaultWallPhased:        "the wall phased out of existence during collision",
+	FaultTimeout:           "the operation timed out",
+}
+
+// ParseFaultKind returns the FaultKind with the given name.
+func ParseFaultKind(name string) (FaultKind, error) {
+	kind := FaultKind(name)
+	if _, ok := faultMessages[kind]; !ok {
+		return "", fmt.Errorf("unknown fault kind '%s'", name)
+	}
+	return kind, nil
+}
+
+// FaultModel describes the chaos injected into one operation.
+type FaultModel struct {
+	// FailureRate is the chance (0 to 1) of a single roll failing.
+	FailureRate float64
+	// Scope decides whether failures are rolled per call or per entity.
+	Scope FaultScope
+	// Kind is the kind of the returned *InjectedFault.
+	Kind FaultKind
+	// Panic makes injected failures panic with the *InjectedFault instead of returning it.
+	Panic bool
+	// SpikeRate is the chance (0 to 1) of a single entity taking SpikeLatency of extra work.
+	SpikeRate    float64
+	SpikeLatency time.Duration
+}
+
+// defaultFaults keeps the historic behavior: one in a thousand calls fails.
+var defaultFaults = map[Operation]FaultModel{
+	OperationFight:       {FailureRate: 0.001, Scope: PerBatch, Kind: FaultBattleInterrupted},
+	OperationSpawnGuards: {FailureRate: 0.001, Scope: PerBatch, Kind: FaultSpawnAnomaly},
+	OperationHitWall:     {FailureRate: 0.001, Scope: PerBatch, Kind: FaultWallPhased},
+}
+
+// InjectedFault is the error (or panic value) produced by a FaultModel. It wraps the sentinel
+// error of its kind, so errors.Is(err, ErrSpawnAnomaly) matches injected spawn anomalies.
+type InjectedFault struct {
+	Operation Operation
+	Kind      FaultKind
+	// Entity is the index of the failing entity, or -1 if the whole batch failed.
+	Entity int
+	// Count is the number of entities of the failing call.
+	Count int
+}
+
+func (f *InjectedFault) Error() string {
+	if f.Entity < 0 {
+		return fmt.Sprintf("%s (%s of %d entities in one iteration)", faultMessages[f.Kind], f.Operation, f.Count)
+	}
+	return fmt.Sprintf("%s (%s, entity %d of %d)", faultMessages[f.Kind], f.Operation, f.Entity+1, f.Count)
+}
+
+// WithFaults replaces the fault model of an operation.
+func WithFaults(op Operation, model FaultModel) Option {
+	return func(s *Simulator) {
+		s.faults[op] = model
+	}
+}
+
+// Faults returns the fault model currently used for an operation.
+func (s *Simulator) Faults(op Operation) FaultModel {
+	return s.faults[op]
+}
+
+// roll reports whether an event with the given chance happens. A zero rate never
+// consumes randomness, so disabled faults do not shift the random stream.
+func (s *Simulator) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	return s.rng.Float64() < rate
+}
+
+// fail returns the fault as error, or panics with it if the model says so.
+func (model FaultModel) fail(op Operation, entity, count int) error {
+	fault := &InjectedFault{Operation: op, Kind: model.Kind, Entity: entity, Count: count}
+	if model.Panic {
+		panic(fault)
+	}
+	return fault
+}
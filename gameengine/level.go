@@ -0,0 +1,335 @@
+package gameengine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Base is the camp of a faction on a battlefield.
+type Base struct {
+	Faction  Faction
+	Position Vec2
+}
+
+// Level describes a battlefield: its walls, where the player starts, where guards spawn
+// and where the factions have their bases. Positions are in world units around the center
+// of the level.
+type Level struct {
+	Name string
+	// Size is the width (X) and height (Y) of the level.
+	Size        Vec2
+	Walls       []AABB
+	PlayerStart Vec2
+	SpawnPoints []Vec2
+	Bases       []Base
+	// PopulationCap and TerrainCost shape guard spawning like those of an Area.
+	PopulationCap int
+	TerrainCost   float64
+}
+
+// Characters of a level grid. Any other character must be a base from the legend.
+const (
+	gridWall        = '#'
+	gridFloor       = '.'
+	gridPlayerStart = '@'
+	gridSpawnPoint  = '*'
+)
+
+// factions are the factions a level can place bases for.
+var factions = map[Faction]bool{
+	FactionShu:           true,
+	FactionWei:           true,
+	FactionWu:            true,
+	FactionYellowTurbans: true,
+}
+
+// levelFile is the file form of a Level. The layout is an ASCII grid, one string per row
+// from north to south; the legend maps base characters of the grid to factions. In JSON it
+// is an object with these fields; as plain text the grid comes first and the settings follow,
+// see ParseLevelText.
+type levelFile struct {
+	Name string `json:"name"`
+	// CellSize is the width of a grid cell in world units, 1 if omitted.
+	CellSize      float64 `json:"cell_size"`
+	PopulationCap int     `json:"population_cap"`
+	// TerrainCost is 1 if omitted.
+	TerrainCost float64           `json:"terrain_cost"`
+	Legend      map[string]string `json:"legend"`
+	Grid        []string          `json:"grid"`
+}
+
+// LevelError reports a level that cannot be parsed or loaded. It wraps ErrInvalidLevel.
+type LevelError struct {
+	Level  string
+	Reason string
+}
+
+func (e *LevelError) Error() string {
+	return fmt.Sprintf("invalid level '%s': %s", e.Level, e.Reason)
+}
+
+func (e *LevelError) Unwrap() error {
+	return ErrInvalidLevel
+}
+
+// ReadLevel reads a level from a file: plain text if its extension is .txt, JSON otherwise.
+// Without a name in the file, the level is named after the file.
+func ReadLevel(path string) (Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Level{}, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if filepath.Ext(path) == ".txt" {
+		return parseLevelText(name, data)
+	}
+	return parseLevel(name, data)
+}
+
+// ParseLevel parses a level from its JSON form.
+func ParseLevel(data []byte) (Level, error) {
+	return parseLevel("", data)
+}
+
+// ParseLevelText parses a level from its plain-text form: the grid rows, then an empty
+// line and one "key: value" setting per line. Single-character keys are legend entries
+// such as "W: Wei"; the other keys are name, cell_size, population_cap and terrain_cost.
+func ParseLevelText(data []byte) (Level, error) {
+	return parseLevelText("", data)
+}
+
+func parseLevel(name string, data []byte) (Level, error) {
+	var file levelFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Level{}, &LevelError{Level: name, Reason: err.Error()}
+	}
+	return file.level(name)
+}
+
+func parseLevelText(name string, data []byte) (Level, error) {
+	var file levelFile
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	row := 0
+	for ; row < len(lines) && lines[row] != ""; row++ {
+		file.Grid = append(file.Grid, lines[row])
+	}
+	for _, line := range lines[row:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return Level{}, &LevelError{Level: name, Reason: fmt.Sprintf("setting '%s' is not of the form 'key: value'", line)}
+		}
+		if err := file.set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return Level{}, &LevelError{Level: name, Reason: err.Error()}
+		}
+	}
+	return file.level(name)
+}
+
+// set applies a setting of the plain-text form.
+func (f *levelFile) set(key, value string) error {
+	var err error
+	switch key {
+	case "name":
+		f.Name = value
+	case "cell_size":
+		f.CellSize, err = strconv.ParseFloat(value, 64)
+	case "population_cap":
+		f.PopulationCap, err = strconv.Atoi(value)
+	case "terrain_cost":
+		f.TerrainCost, err = strconv.ParseFloat(value, 64)
+	default:
+		if utf8.RuneCountInString(key) != 1 {
+			return fmt.Errorf("unknown setting '%s'", key)
+		}
+		if f.Legend == nil {
+			f.Legend = map[string]string{}
+		}
+		f.Legend[key] = value
+	}
+	if err != nil {
+		return fmt.Errorf("setting '%s' has an invalid value '%s'", key, value)
+	}
+	return nil
+}
+
+// level builds the Level described by the file, named name unless the file names it.
+func (f levelFile) level(name string) (Level, error) {
+	if f.Name != "" {
+		name = f.Name
+	}
+	if f.CellSize == 0 {
+		f.CellSize = 1
+	}
+	if f.TerrainCost == 0 {
+		f.TerrainCost = 1
+	}
+	level := Level{Name: name, PopulationCap: f.PopulationCap, TerrainCost: f.TerrainCost}
+	if err := level.readGrid(f.Grid, f.CellSize, f.Legend); err != nil {
+		return Level{}, err
+	}
+	return level, level.validate()
+}
+
+// readGrid fills in the layout of the level from an ASCII grid. Walls are merged into as
+// few boxes as possible: runs of wall cells along a row, stacked over rows with the same run.
+func (l *Level) readGrid(grid []string, cellSize float64, legend map[string]string) error {
+	if len(grid) == 0 {
+		return &LevelError{Level: l.Name, Reason: "the grid is empty"}
+	}
+	if cellSize <= 0 {
+		return &LevelError{Level: l.Name, Reason: fmt.Sprintf("cell size must be positive, got %g", cellSize)}
+	}
+	bases := map[rune]Faction{}
+	for char, name := range legend {
+		runes := []rune(char)
+		faction := Faction(name)
+		if len(runes) != 1 {
+			return &LevelError{Level: l.Name, Reason: fmt.Sprintf("legend key '%s' is not a single character", char)}
+		}
+		switch runes[0] {
+		case gridWall, gridFloor, gridPlayerStart, gridSpawnPoint, ' ':
+			return &LevelError{Level: l.Name, Reason: fmt.Sprintf("legend key '%s' is already a grid character", char)}
+		}
+		if !factions[faction] {
+			return &LevelError{Level: l.Name, Reason: fmt.Sprintf("legend maps '%s' to unknown faction '%s'", char, name)}
+		}
+		bases[runes[0]] = faction
+	}
+
+	rows := make([][]rune, len(grid))
+	for i, row := range grid {
+		rows[i] = []rune(row)
+		if len(rows[i]) != len(rows[0]) {
+			return &LevelError{Level: l.Name, Reason: fmt.Sprintf("row %d is %d cells wide, expected %d", i+1, len(rows[i]), len(rows[0]))}
+		}
+	}
+	width, height := len(rows[0]), len(rows)
+	l.Size = Vec2{float64(width) * cellSize, float64(height) * cellSize}
+	cell := func(col, row int) AABB {
+		min := Vec2{float64(col)*cellSize - l.Size.X/2, l.Size.Y/2 - float64(row+1)*cellSize}
+		return AABB{Min: min, Max: min.Add(Vec2{cellSize, cellSize})}
+	}
+	center := func(col, row int) Vec2 {
+		box := cell(col, row)
+		return box.Min.Add(box.Max).Scale(0.5)
+	}
+
+	starts := 0
+	open := map[[2]int]int{}
+	for r, row := range rows {
+		runs := map[[2]int]int{}
+		for c := 0; c < width; c++ {
+			switch char := row[c]; char {
+			case gridWall:
+				end := c
+				for end < width && row[end] == gridWall {
+					end++
+				}
+				span := [2]int{c, end}
+				if i, ok := open[span]; ok {
+					l.Walls[i].Min.Y = cell(c, r).Min.Y
+					runs[span] = i
+				} else {
+					runs[span] = len(l.Walls)
+					l.Walls = append(l.Walls, AABB{Min: cell(c, r).Min, Max: cell(end-1, r).Max})
+				}
+				c = end - 1
+			case gridFloor, ' ':
+			case gridPlayerStart:
+				l.PlayerStart = center(c, r)
+				starts++
+			case gridSpawnPoint:
+				l.SpawnPoints = append(l.SpawnPoints, center(c, r))
+			default:
+				faction, ok := bases[char]
+				if !ok {
+					return &LevelError{Level: l.Name, Reason: fmt.Sprintf("unknown grid character '%c' in row %d", char, r+1)}
+				}
+				l.Bases = append(l.Bases, Base{Faction: faction, Position: center(c, r)})
+			}
+		}
+		open = runs
+	}
+	if starts != 1 {
+		return &LevelError{Level: l.Name, Reason: fmt.Sprintf("the grid needs exactly one player start '%c', found %d", gridPlayerStart, starts)}
+	}
+	return nil
+}
+
+// validate checks that the level can be played: the player start and the charge lanes
+// around it are clear of the walls, every base belongs to a known faction and guards can
+// be spawned in it.
+func (l Level) validate() error {
+	if l.Name == "" {
+		return &LevelError{Reason: "the level needs a name"}
+	}
+	for _, wall := range l.Walls {
+		if _, inside := CircleAABB(Circle{Center: l.PlayerStart, Radius: PlayerRadius}, wall); inside {
+			return &LevelError{Level: l.Name, Reason: fmt.Sprintf("the player start %v lies inside a wall", l.PlayerStart)}
+		}
+	}
+	// Wall hits charge from lanes north and south of the start, which must be clear too.
+	bounds := l.Area().bounds().expand(-PlayerRadius)
+	for i := 0; i < chargeLanes; i++ {
+		lane := chargeStart(l.PlayerStart, i)
+		if bounds.closestPoint(lane) != lane {
+			return &LevelError{Level: l.Name, Reason: fmt.Sprintf("the charge lane starting at %v leaves the level", lane)}
+		}
+		for _, wall := range l.Walls {
+			if _, inside := CircleAABB(Circle{Center: lane, Radius: PlayerRadius}, wall); inside {
+				return &LevelError{Level: l.Name, Reason: fmt.Sprintf("the charge lane starting at %v lies inside a wall", lane)}
+			}
+		}
+	}
+	for _, base := range l.Bases {
+		if !factions[base.Faction] {
+			return &LevelError{Level: l.Name, Reason: fmt.Sprintf("base at %v belongs to unknown faction '%s'", base.Position, base.Faction)}
+		}
+	}
+	if err := l.Area().validate(); err != nil {
+		return &LevelError{Level: l.Name, Reason: err.Error()}
+	}
+	return nil
+}
+
+// Area returns the area guards spawn in on this level, with the walls as obstacles.
+func (l Level) Area() Area {
+	return Area{
+		Name:          l.Name,
+		Size:          l.Size,
+		Obstacles:     l.Walls,
+		SpawnPoints:   l.SpawnPoints,
+		PopulationCap: l.PopulationCap,
+		TerrainCost:   l.TerrainCost,
+	}
+}
+
+// LoadLevel makes the level the battlefield of the Simulator: its walls replace the
+// current ones, the player moves to its start, enemies charge in from its bases and its
+// area is registered under the level's name. Later operations run against the level.
+func (s *Simulator) LoadLevel(level Level) error {
+	if err := level.validate(); err != nil {
+		return err
+	}
+	if err := s.areas.Register(level.Area()); err != nil {
+		return err
+	}
+	w := s.world
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.walls = append(w.walls[:0], level.Walls...)
+	w.bases = append(w.bases[:0], level.Bases...)
+	w.start = level.PlayerStart
+	w.bounds = level.Area().bounds()
+	w.player.Position = level.PlayerStart
+	return nil
+}
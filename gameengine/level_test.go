@@ -0,0 +1,118 @@
+package gameengine
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// cells returns the box covering columns col0 to col1 and rows row0 to row1 (both exclusive
+// at the end) of a width x height grid with cells of size 1.
+func cells(width, height, col0, row0, col1, row1 int) AABB {
+	left, top := -float64(width)/2, float64(height)/2
+	return AABB{
+		Min: Vec2{left + float64(col0), top - float64(row1)},
+		Max: Vec2{left + float64(col1), top - float64(row0)},
+	}
+}
+
+func TestReadGridMergesWalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		grid  []string
+		walls []AABB
+	}{
+		{
+			name:  "a run along a row is one wall",
+			grid:  []string{"###.", "...@"},
+			walls: []AABB{cells(4, 2, 0, 0, 3, 1)},
+		},
+		{
+			name:  "the same run over several rows is one wall",
+			grid:  []string{"##.", "##.", "##@"},
+			walls: []AABB{cells(3, 3, 0, 0, 2, 3)},
+		},
+		{
+			name:  "a shorter run below starts a new wall",
+			grid:  []string{"###.", "##..", "...@"},
+			walls: []AABB{cells(4, 3, 0, 0, 3, 1), cells(4, 3, 0, 1, 2, 2)},
+		},
+		{
+			name:  "a longer run below starts a new wall",
+			grid:  []string{".#..", "###.", "...@"},
+			walls: []AABB{cells(4, 3, 1, 0, 2, 1), cells(4, 3, 0, 1, 3, 2)},
+		},
+		{
+			name:  "a run shifted sideways starts a new wall",
+			grid:  []string{"##..", ".##.", "...@"},
+			walls: []AABB{cells(4, 3, 0, 0, 2, 1), cells(4, 3, 1, 1, 3, 2)},
+		},
+		{
+			name:  "runs are not merged across a row without them",
+			grid:  []string{"##.", "...", "##@"},
+			walls: []AABB{cells(3, 3, 0, 0, 2, 1), cells(3, 3, 0, 2, 2, 3)},
+		},
+		{
+			name:  "a run split in two below continues as two walls",
+			grid:  []string{"###", "#.#", "#@#"},
+			walls: []AABB{cells(3, 3, 0, 0, 3, 1), cells(3, 3, 0, 1, 1, 3), cells(3, 3, 2, 1, 3, 3)},
+		},
+		{
+			name:  "two runs in a row merge with their own runs below",
+			grid:  []string{"#.##", "#.##", ".@.."},
+			walls: []AABB{cells(4, 3, 0, 0, 1, 2), cells(4, 3, 2, 0, 4, 2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := Level{Name: "test"}
+			if err := level.readGrid(tt.grid, 1, nil); err != nil {
+				t.Fatalf("readGrid failed: %v", err)
+			}
+			if !reflect.DeepEqual(level.Walls, tt.walls) {
+				t.Errorf("walls = %v, want %v", level.Walls, tt.walls)
+			}
+		})
+	}
+}
+
+func TestParseLevelTextMatchesJSON(t *testing.T) {
+	grid := []string{"#####", "#...#", "#...#", "#S@*#", "#...#", "#...#", "#####"}
+	text := strings.Join(grid, "\n") + "\n\nname: gate\ncell_size: 2\npopulation_cap: 5\nS: Shu\n"
+	json := `{"name": "gate", "cell_size": 2, "population_cap": 5, "legend": {"S": "Shu"},
+		"grid": ["` + strings.Join(grid, `", "`) + `"]}`
+
+	fromText, err := ParseLevelText([]byte(text))
+	if err != nil {
+		t.Fatalf("ParseLevelText failed: %v", err)
+	}
+	fromJSON, err := ParseLevel([]byte(json))
+	if err != nil {
+		t.Fatalf("ParseLevel failed: %v", err)
+	}
+	if !reflect.DeepEqual(fromText, fromJSON) {
+		t.Errorf("plain text level = %+v, want %+v", fromText, fromJSON)
+	}
+}
+
+func TestParseLevelTextRejectsBadSettings(t *testing.T) {
+	for _, settings := range []string{"cell_size 2", "cell_size: wide", "speed: 3", "S: Han"} {
+		t.Run(settings, func(t *testing.T) {
+			_, err := ParseLevelText([]byte("#S@#\n\n" + settings + "\n"))
+			if !errors.Is(err, ErrInvalidLevel) {
+				t.Errorf("error = %v, want one wrapping ErrInvalidLevel", err)
+			}
+		})
+	}
+}
+
+func TestParseLevelTextRejectsBlockedChargeLanes(t *testing.T) {
+	// Wall hits charge from four lanes either side of the start; the southmost one would
+	// start inside the bottom wall.
+	grid := []string{"#######", "#.....#", "#.....#", "#.....#", "#.....#", "#..@..#", "#.....#", "#.....#", "#.....#", "#######"}
+	_, err := ParseLevelText([]byte(strings.Join(grid, "\n") + "\n\nname: blocked\n"))
+	if !errors.Is(err, ErrInvalidLevel) || !strings.Contains(err.Error(), "charge lane") {
+		t.Errorf("error = %v, want a blocked charge lane", err)
+	}
+}
@@ -0,0 +1,52 @@
+package gameengine
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Option configures a Simulator, either at construction or later through Apply.
+type Option func(*Simulator)
+
+// combatSeedOffset derives the seed of the combat rolls from the Simulator's seed.
+// Combat rolls use their own stream so critical hits do not shift which calls fail.
+const combatSeedOffset = 0x5eed
+
+// WithSeed makes the random failures and combat rolls of the Simulator reproducible.
+func WithSeed(seed int64) Option {
+	return func(s *Simulator) {
+		WithRandSource(rand.NewSource(seed))(s)
+		s.combatRng = &lockedRand{rng: rand.New(rand.NewSource(seed + combatSeedOffset))}
+	}
+}
+
+// WithRandSource injects the random source used for failure injection.
+func WithRandSource(src rand.Source) Option {
+	return func(s *Simulator) {
+		s.rng = &lockedRand{rng: rand.New(src)}
+	}
+}
+
+// Apply reconfigures the Simulator. It must not be called while operations are running.
+func (s *Simulator) Apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(s)
+	}
+}
+
+// lockedRand makes a *rand.Rand safe to share between concurrent engine calls.
+type lockedRand struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newTimeSeededRand() *lockedRand {
+	return &lockedRand{rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (r *lockedRand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Float64()
+}
@@ -0,0 +1,81 @@
+package gameengine
+
+import "time"
+
+// Physics tuning of wall impacts.
+const (
+	// restitution is the share of the speed into the wall that bounces back out of it.
+	restitution = 0.5
+	// wallFriction is the share of the speed along the wall lost on impact.
+	wallFriction = 0.2
+	// groundFriction slows a knocked back character down, in units per second squared.
+	groundFriction = 20.0
+	// staggerPerSpeed is how long an impact staggers per unit per second of speed into the wall.
+	staggerPerSpeed = 10 * time.Millisecond
+	// maxStagger caps the stagger of a single impact.
+	maxStagger = time.Second
+)
+
+// Impact is a wall contact together with the physics response it caused.
+type Impact struct {
+	Contact
+	// Incoming is the player's velocity right before the contact.
+	Incoming Vec2
+	// Outgoing is the player's velocity right after the contact: reflected off the
+	// surface, damped by restitution and wall friction.
+	Outgoing Vec2
+	// Knockback is how far the player slid back after the contact.
+	Knockback float64
+	// Rest is where the player came to rest.
+	Rest Vec2
+	// Stagger is how long the player cannot act after the contact.
+	Stagger time.Duration
+}
+
+// respond reflects velocity off a surface with the given unit normal. The part going into
+// the surface bounces back scaled by restitution, the part along it loses wallFriction.
+func respond(velocity, normal Vec2) Vec2 {
+	into := normal.Scale(velocity.Dot(normal))
+	along := velocity.Sub(into)
+	return along.Scale(1 - wallFriction).Sub(into.Scale(restitution))
+}
+
+// stagger returns how long an impact at velocity against a surface with the given normal staggers.
+func stagger(velocity, normal Vec2) time.Duration {
+	speedIntoWall := -velocity.Dot(normal)
+	if speedIntoWall <= 0 {
+		return 0
+	}
+	d := time.Duration(speedIntoWall * float64(staggerPerSpeed))
+	if d > maxStagger {
+		return maxStagger
+	}
+	return d
+}
+
+// knockBack slides the player along velocity, slowed down by ground friction tick by tick,
+// until it stops or runs into another wall. It returns the distance slid and the number of
+// collision steps taken.
+func (w *World) knockBack(velocity Vec2) (float64, int) {
+	start := w.player.Position
+	speed := velocity.Len()
+	if speed == 0 {
+		return 0, 0
+	}
+	direction := velocity.Scale(1 / speed)
+	slowdown := groundFriction * physicsTick.Seconds()
+	steps := 0
+	for ; speed > 0; speed -= slowdown {
+		step := collisionStep(speed, direction)
+		perTick := speed * physicsTick.Seconds()
+		for moved := 0.0; moved < perTick; moved += step.Len() {
+			steps++
+			if _, hit := w.moveAndCollide(step); hit {
+				w.player.Velocity = Vec2{}
+				return w.player.Position.Sub(start).Len(), steps
+			}
+		}
+	}
+	w.player.Velocity = Vec2{}
+	return w.player.Position.Sub(start).Len(), steps
+}
@@ -0,0 +1,111 @@
+package gameengine
+
+import "fmt"
+
+// Weapon is the weapon the player fights with.
+type Weapon string
+
+const (
+	WeaponSword   Weapon = "sword"
+	WeaponSpear   Weapon = "spear"
+	WeaponHalberd Weapon = "halberd"
+	WeaponBow     Weapon = "bow"
+)
+
+// weaponAttack is the attack each weapon adds to the player's.
+var weaponAttack = map[Weapon]int{
+	WeaponSword:   0,
+	WeaponSpear:   4,
+	WeaponHalberd: 8,
+	WeaponBow:     2,
+}
+
+// ParseWeapon returns the Weapon with the given name.
+func ParseWeapon(name string) (Weapon, error) {
+	weapon := Weapon(name)
+	if _, ok := weaponAttack[weapon]; !ok {
+		return "", &ProfileError{Field: "weapon", Value: name, Reason: "is not a known weapon"}
+	}
+	return weapon, nil
+}
+
+// Limits of a valid PlayerProfile.
+const (
+	MinPlayerLevel = 1
+	MaxPlayerLevel = 99
+	// MinPlayerSpeed is the slowest the player can move, in world units per second. Slower
+	// charges would take more collision steps than a benchmark can wait for.
+	MinPlayerSpeed = 0.5
+	// MaxPlayerSpeed is the fastest the player can move, in world units per second.
+	MaxPlayerSpeed = 1000.0
+)
+
+// PlayerProfile describes the player an operation runs with. The zero value is not
+// valid: at least Level must be set.
+type PlayerProfile struct {
+	// Level makes fights faster and the player stronger, from MinPlayerLevel to MaxPlayerLevel.
+	Level int
+	// Weapon adds to the player's attack. Empty means WeaponSword.
+	Weapon Weapon
+	// Speed is how fast the player moves, in world units per second. Zero means unset.
+	Speed float64
+	// Stats, if set, replace the stats derived from Level and Weapon.
+	Stats Stats
+}
+
+// ProfileError reports an invalid PlayerProfile field. It wraps ErrInvalidProfile.
+type ProfileError struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+func (e *ProfileError) Error() string {
+	return fmt.Sprintf("invalid player profile: %s %v %s", e.Field, e.Value, e.Reason)
+}
+
+func (e *ProfileError) Unwrap() error {
+	return ErrInvalidProfile
+}
+
+// Validate returns a *ProfileError for the first invalid field, or nil.
+func (p PlayerProfile) Validate() error {
+	if p.Level < MinPlayerLevel || p.Level > MaxPlayerLevel {
+		return &ProfileError{Field: "level", Value: p.Level, Reason: fmt.Sprintf("is not between %d and %d", MinPlayerLevel, MaxPlayerLevel)}
+	}
+	if p.Weapon != "" {
+		if _, err := ParseWeapon(string(p.Weapon)); err != nil {
+			return err
+		}
+	}
+	if p.Speed != 0 && ValidateSpeed(p.Speed) != nil {
+		return &ProfileError{Field: "speed", Value: p.Speed, Reason: fmt.Sprintf("is not between %g and %g units per second", MinPlayerSpeed, MaxPlayerSpeed)}
+	}
+	if p.Stats != (Stats{}) {
+		switch {
+		case p.Stats.MaxHealth <= 0:
+			return &ProfileError{Field: "max health", Value: p.Stats.MaxHealth, Reason: "is not positive"}
+		case p.Stats.Health <= 0 || p.Stats.Health > p.Stats.MaxHealth:
+			return &ProfileError{Field: "health", Value: p.Stats.Health, Reason: fmt.Sprintf("is not between 1 and max health %d", p.Stats.MaxHealth)}
+		case p.Stats.Attack < 0:
+			return &ProfileError{Field: "attack", Value: p.Stats.Attack, Reason: "is negative"}
+		case p.Stats.Defense < 0:
+			return &ProfileError{Field: "defense", Value: p.Stats.Defense, Reason: "is negative"}
+		}
+	}
+	return nil
+}
+
+// stats returns the player's stats for the profile, derived from Level and Weapon unless Stats is set.
+func (p PlayerProfile) stats() Stats {
+	if p.Stats != (Stats{}) {
+		return p.Stats
+	}
+	health := playerBaseStats.MaxHealth + playerLevelUp.MaxHealth*p.Level
+	return Stats{
+		Health:    health,
+		MaxHealth: health,
+		Attack:    playerBaseStats.Attack + playerLevelUp.Attack*p.Level + weaponAttack[p.Weapon],
+		Defense:   playerBaseStats.Defense + playerLevelUp.Defense*p.Level,
+	}
+}
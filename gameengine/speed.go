@@ -0,0 +1,83 @@
+package gameengine
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpeedPresets are the named player speeds, in world units per second.
+var SpeedPresets = map[string]float64{
+	"low":    3,
+	"normal": 6,
+	"high":   12,
+	"dash":   30,
+}
+
+// DefaultSpeed is the speed used when an operation is given none.
+const DefaultSpeed = 6.0
+
+// ParseSpeed reads a speed preset such as "high" or a number of world units per
+// second such as "25", "25 u/s" or "25 units per second".
+func ParseSpeed(text string) (float64, error) {
+	text = strings.TrimSpace(text)
+	if speed, ok := SpeedPresets[text]; ok {
+		return speed, nil
+	}
+	number := text
+	for _, unit := range []string{"units per second", "units/s", "u/s"} {
+		if strings.HasSuffix(number, unit) {
+			number = strings.TrimSpace(strings.TrimSuffix(number, unit))
+			break
+		}
+	}
+	speed, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, &SpeedError{Speed: math.NaN(), Reason: fmt.Sprintf("'%s' is neither a speed preset nor a number of units per second", text)}
+	}
+	return speed, ValidateSpeed(speed)
+}
+
+// SpeedError reports a speed the engine cannot simulate. It wraps ErrInvalidSpeed.
+type SpeedError struct {
+	Speed  float64
+	Reason string
+}
+
+func (e *SpeedError) Error() string {
+	return fmt.Sprintf("invalid speed: %s", e.Reason)
+}
+
+func (e *SpeedError) Unwrap() error {
+	return ErrInvalidSpeed
+}
+
+// ValidateSpeed returns a *SpeedError unless speed is between MinPlayerSpeed and MaxPlayerSpeed.
+// Zero is not a speed: options use it for "unset" and fall back to DefaultSpeed.
+func ValidateSpeed(speed float64) error {
+	if math.IsNaN(speed) || speed < MinPlayerSpeed || speed > MaxPlayerSpeed {
+		return &SpeedError{Speed: speed, Reason: fmt.Sprintf("%g is not between %g and %g units per second", speed, MinPlayerSpeed, MaxPlayerSpeed)}
+	}
+	return nil
+}
+
+// physicsTick is the simulated time between two physics updates of a moving character.
+const physicsTick = time.Second / 60
+
+// maxSubstep is the longest distance a character moves in a single collision step. Faster
+// characters move in several sub-steps per tick so every step stays short compared to
+// the character and the walls, on top of the swept test preventing tunnelling outright.
+const maxSubstep = PlayerRadius
+
+// collisionStep returns the movement of a single collision step of a character moving
+// in direction at speed: one tick's worth, split into equal sub-steps of at most maxSubstep.
+func collisionStep(speed float64, direction Vec2) Vec2 {
+	perTick := speed * physicsTick.Seconds()
+	n := math.Ceil(perTick / maxSubstep)
+	if n < 1 {
+		n = 1
+	}
+	return direction.Scale(perTick / n)
+}
@@ -0,0 +1,121 @@
+package gameengine
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WorkModel decides how the Simulator spends the time it simulates per entity.
+type WorkModel string
+
+const (
+	// WorkSleep sleeps, so it measures scheduler and timer granularity rather than CPU work.
+	WorkSleep WorkModel = "sleep"
+	// WorkBusySpin keeps a core busy polling the clock.
+	WorkBusySpin WorkModel = "busy-spin"
+	// WorkHashing keeps a core busy hashing, a CPU-bound kernel with a tiny working set.
+	WorkHashing WorkModel = "hashing"
+	// WorkMemoryBandwidth streams through a buffer much larger than the CPU caches.
+	WorkMemoryBandwidth WorkModel = "memory-bandwidth"
+)
+
+// ParseWorkModel returns the WorkModel with the given name.
+func ParseWorkModel(name string) (WorkModel, error) {
+	switch model := WorkModel(name); model {
+	case WorkSleep, WorkBusySpin, WorkHashing, WorkMemoryBandwidth:
+		return model, nil
+	}
+	return "", fmt.Errorf("unknown work model '%s'", name)
+}
+
+// WithWorkModel selects how the Simulator simulates work. The default is WorkSleep.
+func WithWorkModel(model WorkModel) Option {
+	return func(s *Simulator) {
+		s.work = model
+	}
+}
+
+// WorkModel returns the work model currently used by the Simulator.
+func (s *Simulator) WorkModel() WorkModel {
+	return s.work
+}
+
+// Do spends duration of wall time on the model's kind of work.
+func (model WorkModel) Do(duration time.Duration) {
+	if duration <= 0 {
+		return
+	}
+	switch model {
+	case WorkBusySpin:
+		spin(duration)
+	case WorkHashing:
+		hash(duration)
+	case WorkMemoryBandwidth:
+		stream(duration)
+	default:
+		SimulateWork(duration)
+	}
+}
+
+// workSink keeps the results of the CPU kernels alive so the compiler cannot drop them.
+var workSink uint64
+
+// kernelBatch is how many kernel iterations run between two looks at the clock.
+const kernelBatch = 64
+
+func spin(duration time.Duration) {
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+	}
+}
+
+func hash(duration time.Duration) {
+	deadline := time.Now().Add(duration)
+	var block [sha256.BlockSize]byte
+	for time.Now().Before(deadline) {
+		for i := 0; i < kernelBatch; i++ {
+			sum := sha256.Sum256(block[:])
+			copy(block[:], sum[:])
+		}
+	}
+	atomic.AddUint64(&workSink, binary.LittleEndian.Uint64(block[:8]))
+}
+
+// streamBufferSize is large enough to defeat the last level cache of common CPUs.
+const streamBufferSize = 64 << 20
+
+var (
+	streamBufferOnce sync.Once
+	streamBuffer     []uint64
+)
+
+func stream(duration time.Duration) {
+	streamBufferOnce.Do(func() {
+		streamBuffer = make([]uint64, streamBufferSize/8)
+		for i := range streamBuffer {
+			streamBuffer[i] = uint64(i)
+		}
+	})
+	deadline := time.Now().Add(duration)
+	// Read one word per 64 byte cache line, starting at a different line on every call
+	// so back to back calls do not find the previous lines still cached.
+	const stride = 8
+	// The modulo is taken in uint64, since converting the time to int first can turn it
+	// negative where int is 32 bits wide.
+	offset := int(uint64(time.Now().UnixNano()/stride) % uint64(len(streamBuffer)))
+	var sum uint64
+	for time.Now().Before(deadline) {
+		for i := 0; i < kernelBatch*stride; i += stride {
+			sum += streamBuffer[offset]
+			offset += stride
+			if offset >= len(streamBuffer) {
+				offset -= len(streamBuffer)
+			}
+		}
+	}
+	atomic.AddUint64(&workSink, sum)
+}
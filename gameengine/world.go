@@ -0,0 +1,312 @@
+package gameengine
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Faction is the side an entity fights for.
+type Faction string
+
+const (
+	FactionShu           Faction = "Shu"
+	FactionWei           Faction = "Wei"
+	FactionWu            Faction = "Wu"
+	FactionYellowTurbans Faction = "Yellow Turbans"
+)
+
+// Vec2 is a position or direction on the battlefield, in world units.
+type Vec2 struct {
+	X, Y float64
+}
+
+// Add returns v + o.
+func (v Vec2) Add(o Vec2) Vec2 { return Vec2{v.X + o.X, v.Y + o.Y} }
+
+// Sub returns v - o.
+func (v Vec2) Sub(o Vec2) Vec2 { return Vec2{v.X - o.X, v.Y - o.Y} }
+
+// Scale returns v * f.
+func (v Vec2) Scale(f float64) Vec2 { return Vec2{v.X * f, v.Y * f} }
+
+// Len returns the length of v.
+func (v Vec2) Len() float64 { return math.Hypot(v.X, v.Y) }
+
+// Dot returns the dot product of v and o.
+func (v Vec2) Dot(o Vec2) float64 { return v.X*o.X + v.Y*o.Y }
+
+// Stats are the combat attributes shared by every entity.
+type Stats struct {
+	Health    int
+	MaxHealth int
+	Attack    int
+	Defense   int
+}
+
+// Alive reports whether the entity still has health left.
+func (s Stats) Alive() bool { return s.Health > 0 }
+
+// Entity is the state shared by players, enemies and guards.
+type Entity struct {
+	ID       int
+	Faction  Faction
+	Position Vec2
+	Stats
+}
+
+// Player is the character controlled by the player.
+type Player struct {
+	Entity
+	Level  int
+	Weapon Weapon
+	// Speed is how fast the player moves, in world units per second.
+	Speed float64
+	// Velocity is the player's current movement, in world units per second.
+	Velocity Vec2
+	// Stagger is how long the last wall impact left the player unable to act.
+	Stagger time.Duration
+}
+
+// Enemy is an opposing soldier the player fights.
+type Enemy struct {
+	Entity
+}
+
+// Guard is a soldier of the player's faction spawned around the player.
+type Guard struct {
+	Entity
+	// Facing is the unit direction from the guard towards the player.
+	Facing Vec2
+}
+
+// Default stats of newly created entities. The player's stats grow with its level.
+var (
+	playerBaseStats = Stats{Health: 200, MaxHealth: 200, Attack: 10, Defense: 5}
+	playerLevelUp   = Stats{Health: 20, MaxHealth: 20, Attack: 2, Defense: 1}
+	enemyStats      = Stats{Health: 30, MaxHealth: 30, Attack: 8, Defense: 2}
+	guardStats      = Stats{Health: 60, MaxHealth: 60, Attack: 6, Defense: 4}
+)
+
+// spawnRadius is the distance from the player at which enemies and guards appear.
+const spawnRadius = 5.0
+
+// enemyRadius is the radius of an enemy's collision circle.
+const enemyRadius = 0.4
+
+// World is the game state the Simulator's operations work on. Entities live in slices
+// of values that are reused between calls, so a steady benchmark does not allocate.
+// Operations hold the World's lock for the whole call.
+type World struct {
+	mu      sync.Mutex
+	nextID  int
+	player  Player
+	enemies []Enemy
+	guards  []Guard
+	walls   []AABB
+	bases   []Base
+	// start is where the player begins every charge at a wall.
+	start Vec2
+	// bounds is the extent of the battlefield.
+	bounds AABB
+}
+
+func newWorld() *World {
+	w := &World{walls: append([]AABB(nil), defaultWalls...), bounds: defaultBounds}
+	w.player = Player{Entity: Entity{ID: w.newID(), Faction: FactionShu, Stats: playerBaseStats}, Level: 1}
+	return w
+}
+
+func (w *World) newID() int {
+	w.nextID++
+	return w.nextID
+}
+
+// Player returns a copy of the player.
+func (w *World) Player() Player {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.player
+}
+
+// Enemies returns a copy of the enemies currently on the battlefield.
+func (w *World) Enemies() []Enemy {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Enemy(nil), w.enemies...)
+}
+
+// Guards returns a copy of the guards currently on the battlefield.
+func (w *World) Guards() []Guard {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Guard(nil), w.guards...)
+}
+
+// applyProfile sets up the player from a validated profile, at full health.
+func (w *World) applyProfile(p PlayerProfile) {
+	w.player.Level = p.Level
+	w.player.Weapon = p.Weapon
+	w.player.Speed = p.Speed
+	w.player.Stats = p.stats()
+}
+
+// ringPosition returns the i-th of n evenly spaced positions around the player.
+func (w *World) ringPosition(i, n int) Vec2 {
+	angle := 2 * math.Pi * float64(i) / float64(n)
+	return w.player.Position.Add(Vec2{math.Cos(angle), math.Sin(angle)}.Scale(spawnRadius))
+}
+
+// enemyStart returns where the i-th of n enemies of a fight charges in from and its faction.
+// On a battlefield with bases of other factions than the player's, the enemies take turns
+// coming from those bases; otherwise Yellow Turbans appear evenly around the player. Either
+// way enemies are kept clear of the walls. fromBase tells whether the enemy left a base.
+func (w *World) enemyStart(i, n int) (pos Vec2, faction Faction, fromBase bool) {
+	hostile := 0
+	for _, base := range w.bases {
+		if base.Faction != w.player.Faction {
+			hostile++
+		}
+	}
+	turn := 0
+	if hostile > 0 {
+		turn = i % hostile
+	}
+	for _, base := range w.bases {
+		if base.Faction == w.player.Faction {
+			continue
+		}
+		if turn == 0 {
+			return pushOut(base.Position, enemyRadius, w.walls), base.Faction, true
+		}
+		turn--
+	}
+	return pushOut(w.ringPosition(i, n), enemyRadius, w.walls), FactionYellowTurbans, false
+}
+
+// spawnEnemy adds an enemy of faction at pos and returns its index.
+func (w *World) spawnEnemy(pos Vec2, faction Faction) int {
+	w.enemies = append(w.enemies, Enemy{Entity: Entity{ID: w.newID(), Faction: faction, Position: pos, Stats: enemyStats}})
+	return len(w.enemies) - 1
+}
+
+// removeEnemy destroys the enemy at index i. The last enemy takes its place.
+func (w *World) removeEnemy(i int) {
+	last := len(w.enemies) - 1
+	w.enemies[i] = w.enemies[last]
+	w.enemies = w.enemies[:last]
+}
+
+// spawnGuard adds a guard of the player's faction at pos and returns its index. The guard
+// faces the player wherever it currently stands, e.g. where a wall hit's knockback left it.
+func (w *World) spawnGuard(pos Vec2) int {
+	guard := Guard{Entity: Entity{ID: w.newID(), Faction: w.player.Faction, Position: pos, Stats: guardStats}}
+	toPlayer := w.player.Position.Sub(pos)
+	if d := toPlayer.Len(); d > 0 {
+		guard.Facing = toPlayer.Scale(1 / d)
+	}
+	w.guards = append(w.guards, guard)
+	return len(w.guards) - 1
+}
+
+// dismissGuards destroys every guard.
+func (w *World) dismissGuards() {
+	w.guards = w.guards[:0]
+}
+
+// Bases returns a copy of the faction bases on the battlefield.
+func (w *World) Bases() []Base {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Base(nil), w.bases...)
+}
+
+// PlayerRadius is the radius of the player's collision circle.
+const PlayerRadius = 0.5
+
+// defaultWalls is the battlefield used until a level is loaded: an arena closed by
+// four walls, with a pillar in front of the wall the player charges at.
+var defaultWalls = []AABB{
+	{Min: Vec2{10, -6}, Max: Vec2{10.5, 6}},
+	{Min: Vec2{-10.5, -6}, Max: Vec2{-10, 6}},
+	{Min: Vec2{-10.5, 6}, Max: Vec2{10.5, 6.5}},
+	{Min: Vec2{-10.5, -6.5}, Max: Vec2{10.5, -6}},
+	{Min: Vec2{6, 2}, Max: Vec2{7, 3}},
+}
+
+// defaultBounds is the extent of the default arena, outer walls included.
+var defaultBounds = AABB{Min: Vec2{-10.5, -6.5}, Max: Vec2{10.5, 6.5}}
+
+// charge is one run of the player at the east wall: from the player start, shifted onto
+// one of chargeLanes lanes, straight east over chargeDistance.
+const (
+	chargeLanes    = 9
+	chargeDistance = 12.0
+)
+
+// chargeStart returns where the charge of hit i begins: start shifted onto the hit's lane.
+func chargeStart(start Vec2, i int) Vec2 {
+	lane := float64(i%chargeLanes) - float64(chargeLanes-1)/2
+	return start.Add(Vec2{0, lane})
+}
+
+// Bounds returns the extent of the battlefield.
+func (w *World) Bounds() AABB {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.bounds
+}
+
+// Walls returns a copy of the walls of the battlefield.
+func (w *World) Walls() []AABB {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]AABB(nil), w.walls...)
+}
+
+// moveAndCollide sweeps the player's circle by move against every wall and resolves the
+// earliest contact. Without a contact the player simply ends up at its destination.
+func (w *World) moveAndCollide(move Vec2) (Contact, bool) {
+	body := Circle{Center: w.player.Position, Radius: PlayerRadius}
+	var first Contact
+	hit := false
+	for _, wall := range w.walls {
+		if contact, ok := SweepCircleAABB(body, move, wall); ok && (!hit || contact.Time < first.Time) {
+			first, hit = contact, true
+		}
+	}
+	if !hit {
+		w.player.Position = body.Center.Add(move)
+		return Contact{}, false
+	}
+	w.player.Position = first.Resolved
+	return first, true
+}
+
+// hitWall lets the player charge east at speed on the lane of hit i, moving step by step
+// until it touches a wall or has covered chargeDistance, and then bounces off the wall.
+// It returns the impact, if any, and the number of collision steps taken.
+func (w *World) hitWall(i int, speed float64) (Impact, int, bool) {
+	w.player.Position = chargeStart(w.start, i)
+	w.player.Speed = speed
+	w.player.Velocity = Vec2{speed, 0}
+	step := collisionStep(speed, Vec2{1, 0})
+	steps := 0
+	for travelled := 0.0; travelled < chargeDistance; travelled += step.X {
+		steps++
+		contact, ok := w.moveAndCollide(step)
+		if !ok {
+			continue
+		}
+		impact := Impact{Contact: contact, Incoming: w.player.Velocity}
+		impact.Outgoing = respond(impact.Incoming, contact.Normal)
+		impact.Stagger = stagger(impact.Incoming, contact.Normal)
+		w.player.Stagger = impact.Stagger
+		knockbackSteps := 0
+		impact.Knockback, knockbackSteps = w.knockBack(impact.Outgoing)
+		impact.Rest = w.player.Position
+		return impact, steps + knockbackSteps, true
+	}
+	w.player.Velocity = Vec2{}
+	return Impact{}, steps, false
+}
@@ -0,0 +1,184 @@
+package benchmarks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+const (
+	// GodogsCtxScenarioKey is the context key for the running *godog.Scenario.
+	GodogsCtxScenarioKey GodogsCtxKey = "scenario"
+	// GodogsCtxBaselineKey is the context key for the baselineRecord the current run is compared against.
+	GodogsCtxBaselineKey GodogsCtxKey = "baseline"
+)
+
+// baselineRecord is what gets persisted for a single measured When step.
+type baselineRecord struct {
+	Feature     string  `json:"feature"`
+	Scenario    string  `json:"scenario"`
+	Parameters  string  `json:"parameters"`
+	TargetCount int     `json:"targetCount"`
+	N           int     `json:"n"`
+	NsPerOp     int64   `json:"nsPerOp"`
+	NsPerItem   float64 `json:"nsPerItem"`
+	AllocsPerOp int64   `json:"allocsPerOp"`
+	BytesPerOp  int64   `json:"bytesPerOp"`
+	// Samples holds ns per item of every repetition, used for significance tests.
+	Samples []float64 `json:"samples,omitempty"`
+	// Mode tells how the rounds were run, see runMode. Empty for calibrated wall clock rounds.
+	Mode       string    `json:"mode,omitempty"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slug turns feature names, scenario names and step parameters into file name friendly keys.
+func slug(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+// baselineFileName keys a record by feature, scenario name, step parameters and run mode,
+// so simulated-time or fixed-size rounds are never compared against calibrated wall clock ones.
+func baselineFileName(record baselineRecord) string {
+	name := slug(record.Feature) + "--" + slug(record.Scenario) + "--" + slug(record.Parameters)
+	if record.Mode != "" {
+		name += "--" + slug(record.Mode)
+	}
+	return name + ".json"
+}
+
+// runMode describes how RunAndReport ran the rounds of the scenario when that differs from
+// calibrated wall clock rounds: on a virtual clock and/or with a fixed number of operations.
+func runMode(ctx context.Context) string {
+	var mode []string
+	if _, virtual := getVirtualClockFromCtx(ctx); virtual {
+		mode = append(mode, "virtual clock")
+	}
+	if iterations, fixed, err := getIterationsFromCtx(ctx); err == nil && fixed {
+		mode = append(mode, fmt.Sprintf("%d operations per round", iterations))
+	}
+	return strings.Join(mode, ", ")
+}
+
+func readBaselineRecord(path string) (*baselineRecord, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline %s: %w", path, err)
+	}
+	var record baselineRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &record, nil
+}
+
+func writeBaselineRecord(path string, record baselineRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// persistRun stores the result of a When step below BENCHMARK_RESULTS_DIR.
+// Every run is written to results/, while baselines/ is only written when no baseline
+// exists yet or BENCHMARK_UPDATE_BASELINE is set. The previous baseline, if any, is put
+// into the context for the regression steps.
+func persistRun(ctx context.Context, parameters string, benchmarkResult testing.BenchmarkResult, targetCount int) (context.Context, error) {
+	resultsDir, ok := os.LookupEnv("BENCHMARK_RESULTS_DIR")
+	if !ok || targetCount == 0 {
+		return ctx, nil
+	}
+	scenario, ok := ctx.Value(GodogsCtxScenarioKey).(*godog.Scenario)
+	if !ok {
+		return ctx, fmt.Errorf("scenario not found in context, cannot persist results")
+	}
+
+	record := baselineRecord{
+		Feature:     strings.TrimSuffix(filepath.Base(scenario.Uri), filepath.Ext(scenario.Uri)),
+		Scenario:    scenario.Name,
+		Parameters:  parameters,
+		Mode:        runMode(ctx),
+		TargetCount: targetCount,
+		N:           benchmarkResult.N,
+		NsPerOp:     benchmarkResult.NsPerOp(),
+		AllocsPerOp: benchmarkResult.AllocsPerOp(),
+		BytesPerOp:  benchmarkResult.AllocedBytesPerOp(),
+		RecordedAt:  time.Now().UTC(),
+	}
+	// A run that processed no items, e.g. one where every call fails, has no per-item figures.
+	if items, err := itemsPerOperation(ctx); err == nil {
+		record.NsPerItem = float64(benchmarkResult.NsPerOp()) / items
+		if samples, err := getSamplesFromCtx(ctx); err == nil {
+			record.Samples = perItemSamples(samples, items)
+		}
+	}
+	fileName := baselineFileName(record)
+
+	if err := writeBaselineRecord(filepath.Join(resultsDir, "results", fileName), record); err != nil {
+		return ctx, err
+	}
+
+	baselinePath := filepath.Join(resultsDir, "baselines", fileName)
+	baseline, err := readBaselineRecord(baselinePath)
+	if err != nil {
+		return ctx, err
+	}
+	_, updateBaseline := os.LookupEnv("BENCHMARK_UPDATE_BASELINE")
+	if baseline == nil || updateBaseline {
+		if err := writeBaselineRecord(baselinePath, record); err != nil {
+			return ctx, err
+		}
+	}
+	return context.WithValue(ctx, GodogsCtxBaselineKey, baseline), nil
+}
+
+func averageTimePerItemShouldNotRegress(ctx context.Context, item string, maxRegressionPercent int) error {
+	benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if _, _, err := measuredOperation(ctx, item); err != nil {
+		return err
+	}
+	items, err := itemsPerOperation(ctx)
+	if err != nil {
+		return err
+	}
+
+	baseline, _ := ctx.Value(GodogsCtxBaselineKey).(*baselineRecord)
+	if baseline == nil || baseline.NsPerItem == 0 {
+		fmt.Printf("  Benchmark Metric: Regression Of Average Time Per %s\n", item)
+		fmt.Printf("    No previous baseline to compare against (baselines are kept in BENCHMARK_RESULTS_DIR)\n")
+		return nil
+	}
+
+	observedNsPerItem := float64(benchmarkResult.NsPerOp()) / items
+	changePercent := (observedNsPerItem - baseline.NsPerItem) / baseline.NsPerItem * 100
+
+	fmt.Printf("  Benchmark Metric: Regression Of Average Time Per %s\n", item)
+	fmt.Printf("    Baseline NsPerItem: %.0f ns (recorded %s)\n", baseline.NsPerItem, baseline.RecordedAt.Format(time.RFC3339))
+	fmt.Printf("    Observed NsPerItem: %.0f ns\n", observedNsPerItem)
+	fmt.Printf("    Change: %+.2f%% (allowed: +%d%%)\n", changePercent, maxRegressionPercent)
+
+	if changePercent > float64(maxRegressionPercent) {
+		return fmt.Errorf("expected average time per %s not to regress by more than %d%%, but it regressed by %.2f%% (%.0f ns -> %.0f ns)",
+			item, maxRegressionPercent, changePercent, baseline.NsPerItem, observedNsPerItem)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dynasty-warriors-godog/gameengine"
+
+	"github.com/cucumber/godog"
+)
+
+// battlefieldsDir is the directory, next to the feature files, holding the battlefield levels.
+const battlefieldsDir = "battlefields"
+
+// battlefieldExtensions are the level file formats gameengine.ReadLevel understands.
+var battlefieldExtensions = []string{".json", ".txt"}
+
+// battlefieldPath returns the level file of a battlefield for the running scenario,
+// either name.json or name.txt.
+func battlefieldPath(ctx context.Context, name string) (string, error) {
+	scenario, ok := ctx.Value(GodogsCtxScenarioKey).(*godog.Scenario)
+	if !ok {
+		return "", fmt.Errorf("scenario not found in context, cannot locate battlefield '%s'", name)
+	}
+	dir := filepath.Join(filepath.Dir(scenario.Uri), battlefieldsDir)
+	for _, ext := range battlefieldExtensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("battlefield '%s' not found: no %s.json or %s.txt in %s", name, name, name, dir)
+}
+
+// battlefieldIsLoaded loads a level into the scenario's engine. Fights and wall hits then
+// start from the level's player start, enemies charge in from its bases and guards spawn
+// in the level's area.
+func battlefieldIsLoaded(ctx context.Context, name string) (context.Context, error) {
+	path, err := battlefieldPath(ctx, name)
+	if err != nil {
+		return ctx, err
+	}
+	level, err := gameengine.ReadLevel(path)
+	if err != nil {
+		return ctx, fmt.Errorf("cannot load battlefield '%s': %w", name, err)
+	}
+	if level.Name != name {
+		return ctx, fmt.Errorf("battlefield file '%s' describes level '%s'", path, level.Name)
+	}
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	if err := simulator.LoadLevel(level); err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, GodogsCtxAreaKey, level.Name), nil
+}
@@ -0,0 +1,251 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// checklistHeader is the expected header row of a performance checklist table.
+var checklistHeader = []string{"metric", "comparator", "value", "unit"}
+
+// checklistRow is a single budget of a performance checklist.
+type checklistRow struct {
+	Metric     string
+	Comparator string
+	Value      string
+	Unit       string
+}
+
+// checklistComparators maps the comparators allowed in a checklist onto their check.
+var checklistComparators = map[string]func(observed, expected float64) bool{
+	"<":  func(observed, expected float64) bool { return observed < expected },
+	"<=": func(observed, expected float64) bool { return observed <= expected },
+	">":  func(observed, expected float64) bool { return observed > expected },
+	">=": func(observed, expected float64) bool { return observed >= expected },
+	"==": func(observed, expected float64) bool { return observed == expected },
+}
+
+// checklistMetric knows how to observe a metric and how to read a budget for it,
+// both in the same base unit (ns, objects, bytes, percent, items per second).
+type checklistMetric struct {
+	observe func(ctx context.Context) (float64, error)
+	expect  func(value float64, unit string) (float64, error)
+	format  func(v float64) string
+}
+
+func formatNs(v float64) string { return time.Duration(v).String() }
+
+func expectDuration(value float64, unit string) (float64, error) {
+	d, err := parseStepDuration(strconv.FormatFloat(value, 'f', -1, 64) + " " + unit)
+	if err != nil {
+		return 0, err
+	}
+	return float64(d.Nanoseconds()), nil
+}
+
+func observePercentilePerItem(statistic string) func(ctx context.Context) (float64, error) {
+	return func(ctx context.Context) (float64, error) {
+		latencies, err := getLatenciesFromCtx(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if _, _, err := measuredOperation(ctx, ""); err != nil {
+			return 0, err
+		}
+		perItem, err := latencyPercentile(latencies, statistic)
+		if err != nil {
+			return 0, err
+		}
+		return float64(perItem), nil
+	}
+}
+
+var checklistMetrics = map[string]checklistMetric{
+	"avg": {
+		observe: func(ctx context.Context) (float64, error) {
+			benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
+			if err != nil {
+				return 0, err
+			}
+			items, err := itemsPerOperation(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return float64(benchmarkResult.NsPerOp()) / items, nil
+		},
+		expect: expectDuration,
+		format: formatNs,
+	},
+	"p50": {observe: observePercentilePerItem("p50"), expect: expectDuration, format: formatNs},
+	"p95": {observe: observePercentilePerItem("p95"), expect: expectDuration, format: formatNs},
+	"p99": {observe: observePercentilePerItem("p99"), expect: expectDuration, format: formatNs},
+	"max": {observe: observePercentilePerItem("max"), expect: expectDuration, format: formatNs},
+	"allocs/op": {
+		observe: func(ctx context.Context) (float64, error) {
+			benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return float64(benchmarkResult.AllocsPerOp()), nil
+		},
+		expect: func(value float64, unit string) (float64, error) {
+			switch unit {
+			case "", "allocs", "objects":
+				return value, nil
+			}
+			return 0, fmt.Errorf("unknown allocation unit '%s'", unit)
+		},
+		format: func(v float64) string { return fmt.Sprintf("%.0f allocs", v) },
+	},
+	"bytes/op": {
+		observe: func(ctx context.Context) (float64, error) {
+			benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return float64(benchmarkResult.AllocedBytesPerOp()), nil
+		},
+		expect: func(value float64, unit string) (float64, error) {
+			if unit == "" {
+				unit = "B"
+			}
+			multiplier, ok := byteUnits[unit]
+			if !ok {
+				return 0, fmt.Errorf("unknown size unit '%s'", unit)
+			}
+			return value * float64(multiplier), nil
+		},
+		format: func(v float64) string { return fmt.Sprintf("%.0f B", v) },
+	},
+	"error rate": {
+		observe: func(ctx context.Context) (float64, error) {
+			op, _, err := measuredOperation(ctx, "")
+			if err != nil {
+				return 0, err
+			}
+			counts, err := getOperationCountsFromCtx(ctx, op.Name)
+			if err != nil {
+				return 0, err
+			}
+			return counts.ErrorRate(), nil
+		},
+		expect: func(value float64, unit string) (float64, error) {
+			if unit != "" && unit != "%" {
+				return 0, fmt.Errorf("error rate must be given in %%, got '%s'", unit)
+			}
+			return value, nil
+		},
+		format: func(v float64) string { return fmt.Sprintf("%.3f%%", v) },
+	},
+	"throughput": {
+		observe: func(ctx context.Context) (float64, error) {
+			benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
+			if err != nil {
+				return 0, err
+			}
+			items, err := itemsPerOperation(ctx)
+			if err != nil {
+				return 0, err
+			}
+			if benchmarkResult.NsPerOp() == 0 {
+				return 0, fmt.Errorf("no time was measured")
+			}
+			return items / (float64(benchmarkResult.NsPerOp()) / 1e9), nil
+		},
+		expect: func(value float64, unit string) (float64, error) {
+			if unit != "" && !strings.HasSuffix(unit, "/s") && !strings.HasSuffix(unit, " per second") {
+				return 0, fmt.Errorf("throughput must be given per second, got '%s'", unit)
+			}
+			return value, nil
+		},
+		format: func(v float64) string { return fmt.Sprintf("%.1f/s", v) },
+	},
+}
+
+func parseChecklist(table *godog.Table) ([]checklistRow, error) {
+	if table == nil || len(table.Rows) < 2 {
+		return nil, fmt.Errorf("performance checklist needs a header row and at least one budget")
+	}
+	header := table.Rows[0].Cells
+	if len(header) != len(checklistHeader) {
+		return nil, fmt.Errorf("performance checklist header must be %s", strings.Join(checklistHeader, " | "))
+	}
+	for i, cell := range header {
+		if strings.TrimSpace(cell.Value) != checklistHeader[i] {
+			return nil, fmt.Errorf("performance checklist header must be %s", strings.Join(checklistHeader, " | "))
+		}
+	}
+
+	rows := make([]checklistRow, 0, len(table.Rows)-1)
+	for _, row := range table.Rows[1:] {
+		rows = append(rows, checklistRow{
+			Metric:     strings.TrimSpace(row.Cells[0].Value),
+			Comparator: strings.TrimSpace(row.Cells[1].Value),
+			Value:      strings.TrimSpace(row.Cells[2].Value),
+			Unit:       strings.TrimSpace(row.Cells[3].Value),
+		})
+	}
+	return rows, nil
+}
+
+// evaluate checks a single row, returning the formatted observation and whether it held.
+func (row checklistRow) evaluate(ctx context.Context) (string, bool, error) {
+	metric, ok := checklistMetrics[row.Metric]
+	if !ok {
+		return "", false, fmt.Errorf("unknown metric '%s'", row.Metric)
+	}
+	compare, ok := checklistComparators[row.Comparator]
+	if !ok {
+		return "", false, fmt.Errorf("unknown comparator '%s'", row.Comparator)
+	}
+	value, err := strconv.ParseFloat(row.Value, 64)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid value '%s': %w", row.Value, err)
+	}
+	expected, err := metric.expect(value, row.Unit)
+	if err != nil {
+		return "", false, err
+	}
+	observed, err := metric.observe(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return metric.format(observed), compare(observed, expected), nil
+}
+
+// performanceChecklistShouldHold evaluates every row of the checklist and reports all
+// of them, failing with the list of rows that did not hold.
+func performanceChecklistShouldHold(ctx context.Context, table *godog.Table) error {
+	rows, err := parseChecklist(table)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Benchmark Metric: Performance Checklist\n")
+	var failures []string
+	for _, row := range rows {
+		budget := strings.TrimSpace(fmt.Sprintf("%s %s %s %s", row.Metric, row.Comparator, row.Value, row.Unit))
+		observed, held, err := row.evaluate(ctx)
+		switch {
+		case err != nil:
+			fmt.Printf("    [ERROR] %-30s %v\n", budget, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", budget, err))
+		case held:
+			fmt.Printf("    [PASS]  %-30s observed %s\n", budget, observed)
+		default:
+			fmt.Printf("    [FAIL]  %-30s observed %s\n", budget, observed)
+			failures = append(failures, fmt.Sprintf("%s (observed %s)", budget, observed))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d performance checklist items did not hold:\n  %s",
+			len(failures), len(rows), strings.Join(failures, "\n  "))
+	}
+	return nil
+}
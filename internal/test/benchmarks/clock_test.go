@@ -0,0 +1,79 @@
+package benchmarks
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"dynasty-warriors-godog/gameengine"
+)
+
+// virtualClockIterations is how many operations a benchmark round runs on a virtual clock.
+// Simulated time is deterministic, so there is nothing for testing.Benchmark to calibrate.
+const virtualClockIterations = 100
+
+// virtualClockEnabled reports whether BENCHMARK_VIRTUAL_CLOCK puts every scenario on a virtual clock.
+func virtualClockEnabled() bool {
+	_, ok := os.LookupEnv("BENCHMARK_VIRTUAL_CLOCK")
+	return ok
+}
+
+// getVirtualClockFromCtx returns the virtual clock of the scenario's engine, if it runs on one.
+func getVirtualClockFromCtx(ctx context.Context) (*gameengine.VirtualClock, bool) {
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return nil, false
+	}
+	clock, ok := simulator.Clock().(*gameengine.VirtualClock)
+	return clock, ok
+}
+
+func engineRunsOnVirtualClock(ctx context.Context) (context.Context, error) {
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	simulator.Apply(gameengine.WithClock(gameengine.NewVirtualClock(time.Time{})))
+	return ctx, nil
+}
+
+// wallClock times fixed rounds that do not run on a virtual clock.
+type wallClock struct{}
+
+func (wallClock) Now() time.Time { return time.Now() }
+
+// runFixedRound runs exactly n operations timed by clock. It stands in for testing.Benchmark
+// on a virtual clock, where calibrating would spin for a second of wall time, and whenever the
+// scenario fixes the operations per round so a failure can be replayed. Allocations are still
+// real and measured the way testing.B measures them.
+func runFixedRound(ctx context.Context, clock gameengine.Clock, n int, entities *entityLatencies, benchmarkFunc func() error) (testing.BenchmarkResult, []time.Duration, *errorCollector, error) {
+	errs := newErrorCollector()
+	roundErrors := make([]error, 0, n)
+	reseedErr := reseedEngine(ctx)
+	entities.reset(n)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := clock.Now()
+	for i := 0; i < n; i++ {
+		if err := benchmarkFunc(); err != nil {
+			roundErrors = append(roundErrors, err)
+		}
+	}
+	elapsed := clock.Now().Sub(start)
+	runtime.ReadMemStats(&after)
+	for _, err := range roundErrors {
+		errs.track(err)
+	}
+
+	result := testing.BenchmarkResult{
+		N:         n,
+		T:         elapsed,
+		MemAllocs: after.Mallocs - before.Mallocs,
+		MemBytes:  after.TotalAlloc - before.TotalAlloc,
+	}
+	return result, entities.measured(), errs, reseedErr
+}
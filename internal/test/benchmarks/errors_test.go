@@ -0,0 +1,365 @@
+package benchmarks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"dynasty-warriors-godog/gameengine"
+)
+
+const (
+	// GodogsCtxAttemptedKey is the context key for how many operations the last RunAndReport attempted.
+	GodogsCtxAttemptedKey GodogsCtxKey = "attempted"
+	// GodogsCtxOperationCountsKey is the context key for the operationCounts of every operation type in the scenario.
+	GodogsCtxOperationCountsKey GodogsCtxKey = "operationCounts"
+)
+
+// operationCounts tracks how many operations of one type were attempted and how many failed.
+type operationCounts struct {
+	Attempted int
+	Failed    int
+}
+
+// ErrorRate returns the share of failed operations in percent.
+func (c operationCounts) ErrorRate() float64 {
+	if c.Attempted == 0 {
+		return 0
+	}
+	return float64(c.Failed) / float64(c.Attempted) * 100
+}
+
+// recordOperationCounts adds attempted and failed operations of one type to the scenario totals.
+func recordOperationCounts(ctx context.Context, operationName string, attempted, failed int) context.Context {
+	previous, _ := ctx.Value(GodogsCtxOperationCountsKey).(map[string]operationCounts)
+	counts := make(map[string]operationCounts, len(previous)+1)
+	for name, c := range previous {
+		counts[name] = c
+	}
+	c := counts[operationName]
+	c.Attempted += attempted
+	c.Failed += failed
+	counts[operationName] = c
+	return context.WithValue(ctx, GodogsCtxOperationCountsKey, counts)
+}
+
+func getOperationCountsFromCtx(ctx context.Context, operationName string) (operationCounts, error) {
+	counts, _ := ctx.Value(GodogsCtxOperationCountsKey).(map[string]operationCounts)
+	c, ok := counts[operationName]
+	if !ok || c.Attempted == 0 {
+		return operationCounts{}, fmt.Errorf("no '%s' operations have been attempted", operationName)
+	}
+	return c, nil
+}
+
+// errorRateShouldBeBelow checks an error budget, e.g. "the fight error rate should be below 0.1%".
+func errorRateShouldBeBelow(ctx context.Context, operationName string, percent string) error {
+	maxRate, err := strconv.ParseFloat(percent, 64)
+	if err != nil {
+		return fmt.Errorf("invalid error rate '%s': %w", percent, err)
+	}
+	counts, err := getOperationCountsFromCtx(ctx, operationName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Benchmark Metric: %s Error Rate\n", operationName)
+	fmt.Printf("    Attempted Operations: %d\n", counts.Attempted)
+	fmt.Printf("    Failed Operations: %d\n", counts.Failed)
+	fmt.Printf("    Observed Error Rate: %.3f%%\n", counts.ErrorRate())
+	fmt.Printf("    Expected Max Error Rate: %s%%\n", percent)
+
+	if counts.ErrorRate() >= maxRate {
+		return fmt.Errorf("expected the %s error rate to be below %s%%, but %d of %d operations failed (%.3f%%)",
+			operationName, percent, counts.Failed, counts.Attempted, counts.ErrorRate())
+	}
+	return nil
+}
+
+// maxErrorSamples bounds how many errors an errorCollector keeps verbatim.
+const maxErrorSamples = 5
+
+// errorDigits is replaced in error messages when grouping, so "entity 3 of 50" and
+// "entity 7 of 50" count towards the same group.
+var errorDigits = regexp.MustCompile(`\d+`)
+
+// errorGroup counts the errors sharing a type and message.
+type errorGroup struct {
+	Type    string
+	Message string
+	Count   int
+}
+
+// The error kinds besides the engine's fault kinds.
+const (
+	invalidCountKind   = "invalid count"
+	invalidProfileKind = "invalid profile"
+	invalidSpeedKind   = "invalid speed"
+	unknownAreaKind    = "unknown area"
+	populationCapKind  = "population cap"
+	playerDefeatedKind = "player defeated"
+	otherErrorKind     = "other"
+)
+
+// errorKind classifies err by the engine's typed errors, e.g. "spawn anomaly".
+func errorKind(err error) string {
+	var fault *gameengine.InjectedFault
+	switch {
+	case errors.As(err, &fault):
+		return string(fault.Kind)
+	case errors.Is(err, gameengine.ErrInvalidCount):
+		return invalidCountKind
+	case errors.Is(err, gameengine.ErrInvalidProfile):
+		return invalidProfileKind
+	case errors.Is(err, gameengine.ErrInvalidSpeed):
+		return invalidSpeedKind
+	case errors.Is(err, gameengine.ErrUnknownArea):
+		return unknownAreaKind
+	case errors.Is(err, gameengine.ErrPopulationCap):
+		return populationCapKind
+	case errors.Is(err, gameengine.ErrPlayerDefeated):
+		return playerDefeatedKind
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return string(gameengine.FaultTimeout)
+	}
+	return otherErrorKind
+}
+
+// parseErrorKind validates an error kind used in step text.
+func parseErrorKind(name string) (string, error) {
+	switch name {
+	case invalidCountKind, invalidProfileKind, invalidSpeedKind, unknownAreaKind, populationCapKind, playerDefeatedKind, otherErrorKind:
+		return name, nil
+	}
+	kind, err := gameengine.ParseFaultKind(name)
+	if err != nil {
+		return "", fmt.Errorf("unknown error kind '%s'", name)
+	}
+	return string(kind), nil
+}
+
+// errorCollector counts every error of a benchmark run. Unlike a buffered channel it never
+// drops an error: all of them are counted and grouped, only the samples are bounded.
+type errorCollector struct {
+	mu     sync.Mutex
+	total  int
+	kinds  map[string]int
+	groups map[string]*errorGroup
+	// byMessage caches the kind and group of every exact type and message seen, so a run
+	// failing the same way a million times only classifies the error once.
+	byMessage map[string]trackedError
+	samples   []error
+}
+
+// trackedError is what an errorCollector remembers about an exact error message.
+type trackedError struct {
+	kind  string
+	group *errorGroup
+}
+
+func newErrorCollector() *errorCollector {
+	return &errorCollector{kinds: map[string]int{}, groups: map[string]*errorGroup{}, byMessage: map[string]trackedError{}}
+}
+
+// track records err if it is not nil. It is safe for concurrent use.
+func (c *errorCollector) track(err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total++
+	errType := fmt.Sprintf("%T", err)
+	exact := errType + "\x00" + err.Error()
+	tracked, ok := c.byMessage[exact]
+	if !ok {
+		message := errorDigits.ReplaceAllString(err.Error(), "N")
+		key := errType + "\x00" + message
+		group, ok := c.groups[key]
+		if !ok {
+			group = &errorGroup{Type: errType, Message: message}
+			c.groups[key] = group
+		}
+		tracked = trackedError{kind: errorKind(err), group: group}
+		c.byMessage[exact] = tracked
+	}
+	c.kinds[tracked.kind]++
+	tracked.group.Count++
+	if len(c.samples) < maxErrorSamples {
+		c.samples = append(c.samples, err)
+	}
+}
+
+// merge adds every error tracked by other, keeping the samples bounded.
+func (c *errorCollector) merge(other *errorCollector) {
+	if other == nil || other == c {
+		return
+	}
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total += other.total
+	for kind, n := range other.kinds {
+		c.kinds[kind] += n
+	}
+	for key, g := range other.groups {
+		group, ok := c.groups[key]
+		if !ok {
+			group = &errorGroup{Type: g.Type, Message: g.Message}
+			c.groups[key] = group
+		}
+		group.Count += g.Count
+	}
+	for _, e := range other.samples {
+		if len(c.samples) >= maxErrorSamples {
+			break
+		}
+		c.samples = append(c.samples, e)
+	}
+}
+
+// Total returns how many errors were tracked.
+func (c *errorCollector) Total() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Kind returns how many errors of the given kind, see errorKind, were tracked.
+func (c *errorCollector) Kind(kind string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.kinds[kind]
+}
+
+// Kinds returns the number of tracked errors per kind.
+func (c *errorCollector) Kinds() map[string]int {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kinds := make(map[string]int, len(c.kinds))
+	for kind, n := range c.kinds {
+		kinds[kind] = n
+	}
+	return kinds
+}
+
+// Samples returns the first tracked errors, at most maxErrorSamples of them.
+func (c *errorCollector) Samples() []error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]error(nil), c.samples...)
+}
+
+// Groups returns the error groups, most frequent first.
+func (c *errorCollector) Groups() []errorGroup {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	groups := make([]errorGroup, 0, len(c.groups))
+	for _, g := range c.groups {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Message < groups[j].Message
+	})
+	return groups
+}
+
+// printErrorReport prints the totals, groups and samples of the collected errors.
+func printErrorReport(operationName string, errs *errorCollector) {
+	fmt.Printf("  Benchmark Metric: %s Errors\n", operationName)
+	fmt.Printf("    Total Errors: %d\n", errs.Total())
+	kinds := errs.Kinds()
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Strings(names)
+	for _, kind := range names {
+		fmt.Printf("    Kind %s: %d\n", kind, kinds[kind])
+	}
+	for _, g := range errs.Groups() {
+		fmt.Printf("    %dx %s: %s\n", g.Count, g.Type, g.Message)
+	}
+	for i, e := range errs.Samples() {
+		fmt.Printf("    Sample %d: %v\n", i+1, e)
+	}
+}
+
+// noErrorsOfKindShouldOccur checks that the last measured operation returned no errors of
+// one kind, e.g. "no spawn anomaly errors should occur", whatever else failed.
+func noErrorsOfKindShouldOccur(ctx context.Context, kindName string) error {
+	kind, err := parseErrorKind(kindName)
+	if err != nil {
+		return err
+	}
+	op, _, err := measuredOperation(ctx, "")
+	if err != nil {
+		return err
+	}
+	errs := getErrorFromCtx(ctx)
+	n := errs.Kind(kind)
+
+	fmt.Printf("  Benchmark Metric: %s Errors of Kind '%s'\n", op.Name, kind)
+	fmt.Printf("    Observed: %d of %d errors\n", n, errs.Total())
+
+	if n > 0 {
+		printErrorReport(op.Name, errs)
+		return fmt.Errorf("expected no %s errors during %s operations, but found %d", kind, op.Name, n)
+	}
+	return nil
+}
+
+// allOperationsShouldFailWith checks that the engine rejected every operation of the last
+// run with errors of one kind, e.g. "all fight operations should fail with invalid profile errors".
+func allOperationsShouldFailWith(ctx context.Context, operationName string, kindName string) error {
+	kind, err := parseErrorKind(kindName)
+	if err != nil {
+		return err
+	}
+	op, _, err := measuredOperation(ctx, "")
+	if err != nil {
+		return err
+	}
+	if op.Name != operationName {
+		return fmt.Errorf("step asks about '%s' but the last measured operation was '%s'", operationName, op.Name)
+	}
+	attempted, err := getIntFromCtx(ctx, GodogsCtxAttemptedKey)
+	if err != nil {
+		return err
+	}
+	errs := getErrorFromCtx(ctx)
+
+	fmt.Printf("  Benchmark Metric: %s Errors of Kind '%s'\n", op.Name, kind)
+	fmt.Printf("    Observed: %d of %d operations\n", errs.Kind(kind), attempted)
+
+	if errs.Kind(kind) != attempted || errs.Total() != attempted {
+		printErrorReport(op.Name, errs)
+		return fmt.Errorf("expected all %d %s operations to fail with %s errors, but %d did", attempted, op.Name, kind, errs.Kind(kind))
+	}
+	return nil
+}
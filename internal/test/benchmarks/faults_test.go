@@ -0,0 +1,117 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"dynasty-warriors-godog/gameengine"
+
+	"github.com/cucumber/godog"
+)
+
+// updateFaults changes the fault model of the operation behind events ("fights", "wall hits", ...).
+func updateFaults(ctx context.Context, events string, update func(*gameengine.FaultModel) error) (context.Context, error) {
+	op, ok := operations.byEvents[events]
+	if !ok {
+		return ctx, fmt.Errorf("unknown operation events '%s'", events)
+	}
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	model := simulator.Faults(op.Operation)
+	if err := update(&model); err != nil {
+		return ctx, err
+	}
+	simulator.Apply(gameengine.WithFaults(op.Operation, model))
+	return ctx, nil
+}
+
+func parsePercentage(text string) (float64, error) {
+	percent, err := strconv.ParseFloat(text, 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("percentage must be between 0 and 100, got '%s'", text)
+	}
+	return percent / 100, nil
+}
+
+// percentOfEventsFail configures injected failures, e.g. "2% of guard spawns fail" or
+// "1% of fights panic per batch". Without a scope every single entity is rolled.
+func percentOfEventsFail(ctx context.Context, percent string, events string, outcome string, scope string) (context.Context, error) {
+	rate, err := parsePercentage(percent)
+	if err != nil {
+		return ctx, err
+	}
+	return updateFaults(ctx, events, func(model *gameengine.FaultModel) error {
+		model.FailureRate = rate
+		model.Panic = outcome == "panic"
+		model.Scope = gameengine.PerEntity
+		if scope == " per batch" {
+			model.Scope = gameengine.PerBatch
+		}
+		return nil
+	})
+}
+
+// noEventsFail switches injected failures off, e.g. "no wall hits fail". Scenarios asserting
+// zero errors use it, since with the default rate a long enough round always fails somewhere.
+func noEventsFail(ctx context.Context, events string) (context.Context, error) {
+	return updateFaults(ctx, events, func(model *gameengine.FaultModel) error {
+		model.FailureRate = 0
+		return nil
+	})
+}
+
+// percentOfEventsTakeExtra configures latency spikes, e.g. "1% of wall hits take an extra 5 ms".
+func percentOfEventsTakeExtra(ctx context.Context, percent string, events string, extra string) (context.Context, error) {
+	rate, err := parsePercentage(percent)
+	if err != nil {
+		return ctx, err
+	}
+	latency, err := parseStepDuration(extra)
+	if err != nil {
+		return ctx, err
+	}
+	return updateFaults(ctx, events, func(model *gameengine.FaultModel) error {
+		model.SpikeRate = rate
+		model.SpikeLatency = latency
+		return nil
+	})
+}
+
+// failingEventsFailWith picks the kind of the injected errors, e.g. "failing wall hits fail with 'timeout' errors".
+func failingEventsFailWith(ctx context.Context, events string, kindName string) (context.Context, error) {
+	kind, err := gameengine.ParseFaultKind(kindName)
+	if err != nil {
+		return ctx, err
+	}
+	return updateFaults(ctx, events, func(model *gameengine.FaultModel) error {
+		model.Kind = kind
+		return nil
+	})
+}
+
+// callRecoveringPanics turns a panic of an engine call, e.g. an injected fault, into an error
+// so a single panicking iteration does not take down the whole benchmark.
+func callRecoveringPanics(ctx context.Context, call engineCall) (result gameengine.Result, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if panicErr, ok := recovered.(error); ok {
+				err = fmt.Errorf("engine call panicked: %w", panicErr)
+				return
+			}
+			err = fmt.Errorf("engine call panicked: %v", recovered)
+		}
+	}()
+	return call(ctx)
+}
+
+// bindFaultSteps registers the Given steps configuring fault injection.
+func bindFaultSteps(scenarioCtx *godog.ScenarioContext) {
+	events := operations.eventsPattern()
+	scenarioCtx.Step(`^(\d+(?:\.\d+)?)% of `+events+` (fail|panic)( per batch| per entity)?$`, percentOfEventsFail)
+	scenarioCtx.Step(`^no `+events+` fail$`, noEventsFail)
+	scenarioCtx.Step(`^(\d+(?:\.\d+)?)% of `+events+` take an extra `+durationPattern+`$`, percentOfEventsTakeExtra)
+	scenarioCtx.Step(`^failing `+events+` fail with '([^']*)' errors$`, failingEventsFailWith)
+}
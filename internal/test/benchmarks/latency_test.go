@@ -0,0 +1,86 @@
+package benchmarks
+
+import (
+	"context"
+	"time"
+
+	"dynasty-warriors-godog/gameengine"
+)
+
+const (
+	// GodogsCtxEntityLatenciesKey is the context key for the *entityLatencies recording the
+	// time of every entity the running When step processes.
+	GodogsCtxEntityLatenciesKey GodogsCtxKey = "entityLatencies"
+	// GodogsCtxProcessedKey is the context key for how many entities the measured rounds processed.
+	GodogsCtxProcessedKey GodogsCtxKey = "processed"
+)
+
+// maxEntityLatencies caps how many entity latencies a round keeps, so long calibrated
+// rounds do not hold gigabytes of durations. Later entities of the round are not recorded.
+const maxEntityLatencies = 1 << 20
+
+// entityLatencies records the time of every enemy, guard or hit an engine call processes.
+// The buffer is sized before a round starts, so recording does not allocate while the
+// benchmark timer runs.
+type entityLatencies struct {
+	perOperation int
+	durations    []time.Duration
+	// processed counts every entity since the last reset, including those beyond the buffer.
+	processed int
+}
+
+// newEntityLatencies returns a recorder for operations processing perOperation entities each.
+func newEntityLatencies(perOperation int) *entityLatencies {
+	return &entityLatencies{perOperation: perOperation}
+}
+
+// observe is the gameengine.EntityObserver of the recorder.
+func (l *entityLatencies) observe(_ gameengine.Operation, took time.Duration) {
+	l.processed++
+	if len(l.durations) < cap(l.durations) {
+		l.durations = append(l.durations, took)
+	}
+}
+
+// reset discards the recorded latencies and makes room for a round of the given operations.
+func (l *entityLatencies) reset(operations int) {
+	l.processed = 0
+	n := operations * l.perOperation
+	if n > maxEntityLatencies || n < 0 {
+		n = maxEntityLatencies
+	}
+	if cap(l.durations) < n {
+		l.durations = make([]time.Duration, 0, n)
+		return
+	}
+	l.durations = l.durations[:0]
+}
+
+// measured returns a copy of the latencies recorded since the last reset.
+func (l *entityLatencies) measured() []time.Duration {
+	measured := make([]time.Duration, len(l.durations))
+	copy(measured, l.durations)
+	return measured
+}
+
+// recordEntityLatencies reports the entities of the scenario's engine to a new recorder
+// and stores it in the context for RunAndReport.
+func recordEntityLatencies(ctx context.Context, perOperation int) (context.Context, error) {
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	latencies := newEntityLatencies(perOperation)
+	simulator.Apply(gameengine.WithEntityObserver(latencies.observe))
+	return context.WithValue(ctx, GodogsCtxEntityLatenciesKey, latencies), nil
+}
+
+// getEntityLatenciesFromCtx returns the recorder of the running When step, or one that
+// records nothing if the engine cannot report entities.
+func getEntityLatenciesFromCtx(ctx context.Context) *entityLatencies {
+	latencies, _ := ctx.Value(GodogsCtxEntityLatenciesKey).(*entityLatencies)
+	if latencies == nil {
+		return newEntityLatencies(0)
+	}
+	return latencies
+}
@@ -3,10 +3,13 @@ package benchmarks
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	// "strings" // Not used directly in this snippet, but good to keep if TestBenchmark evolves
 	"testing"
+	"time"
 
 	"dynasty-warriors-godog/gameengine"
 
@@ -20,14 +23,18 @@ type GodogsCtxKey string
 const (
 	// GodogsCtxBenchmarkResultKey is the context key for storing testing.BenchmarkResult.
 	GodogsCtxBenchmarkResultKey GodogsCtxKey = "benchmarkResult"
-	// GodogsCtxErrorKey is the context key for storing errors from benchmarked operations.
+	// GodogsCtxErrorKey is the context key for the *errorCollector of the benchmarked operation.
 	GodogsCtxErrorKey GodogsCtxKey = "benchmarkError"
-	// GodogsCtxPlayerLevelKey is the context key for player level.
-	GodogsCtxPlayerLevelKey GodogsCtxKey = "playerLevel"
+	// GodogsCtxPlayerProfileKey is the context key for the gameengine.PlayerProfile built by the Given steps.
+	GodogsCtxPlayerProfileKey GodogsCtxKey = "playerProfile"
 	// GodogsCtxAreaKey is the context key for area name.
 	GodogsCtxAreaKey GodogsCtxKey = "areaName"
     // GodogsCtxTargetCountKey is the context key for things like number of enemies, guards etc.
     GodogsCtxTargetCountKey GodogsCtxKey = "targetCount"
+	// GodogsCtxEngineKey is the context key for the gameengine.Engine driven by the scenario.
+	GodogsCtxEngineKey GodogsCtxKey = "engine"
+	// GodogsCtxLatenciesKey is the context key for the per-entity latencies of the measured benchmark round.
+	GodogsCtxLatenciesKey GodogsCtxKey = "latencies"
 )
 
 // TestAndBenchCommon provides common logging and naming for tests and benchmarks.
@@ -54,59 +61,167 @@ func (c TestAndBenchCommon) Logf(format string, args ...interface{}) {
 	c.logf(format, args...)
 }
 
-// makeErrorChannel creates a buffered channel for errors.
-func makeErrorChannel(bufferSize int) chan error {
-	return make(chan error, bufferSize)
-}
-
-// trackBenchmarkError sends an error to the channel if it's not nil.
-func trackBenchmarkError(b *testing.B, err error, errorChannel chan error) {
-	if err != nil {
-		select {
-		case errorChannel <- err:
-		default:
-			b.Logf("Error channel full, dropping error: %v", err)
-		}
-	}
-}
-
 // RunAndReport executes a benchmark function, captures its result and errors.
+// The error returned by benchmarkFunc is the outcome of one operation; errors, operation
+// counts, timings and the latencies of single entities (see entityLatencies) all come
+// from the measured round of testing.Benchmark, while the
+// calibration rounds before it are only logged. On a virtual clock every sample is a single
+// round of virtualClockIterations operations timed in simulated time, and with a fixed number
+// of operations per round every sample runs exactly that many, see runFixedRound.
+// An invalid benchmark setting or an engine that cannot be reseeded fails the call instead
+// of counting as an operation error.
 func RunAndReport(
-	benchmarkFunc func(b *testing.B) error,
-	errorChannel chan error,
+	benchmarkFunc func() error,
+	errs *errorCollector,
 	c TestAndBenchCommon,
 	ctx context.Context,
-) (context.Context, testing.BenchmarkResult, []error) {
+) (context.Context, testing.BenchmarkResult, *errorCollector, error) {
 	
-	var overallErr error
-	benchmarkResult := testing.Benchmark(func(b *testing.B) {
-		b.ResetTimer()
-		for i := 0; i < b.N; i++ {
-			err := benchmarkFunc(b) 
-			if err != nil && overallErr == nil {
-				overallErr = err
+	repetitions, err := getRepetitionsFromCtx(ctx)
+	if err != nil {
+		return ctx, testing.BenchmarkResult{}, errs, err
+	}
+	iterations, fixed, err := getIterationsFromCtx(ctx)
+	if err != nil {
+		return ctx, testing.BenchmarkResult{}, errs, err
+	}
+	if err := reseedEngine(ctx); err != nil {
+		return ctx, testing.BenchmarkResult{}, errs, err
+	}
+
+	var reseedErr error
+	attempted := 0
+	processed := 0
+	calibration := struct{ attempted, errors int }{}
+	var latencies []time.Duration
+	var samples []float64
+	var benchmarkResult testing.BenchmarkResult
+	var rounds []int
+	entities := getEntityLatenciesFromCtx(ctx)
+	clock, virtual := getVirtualClockFromCtx(ctx)
+	for sample := 0; sample < repetitions; sample++ {
+		var sampleLatencies []time.Duration
+		var roundErrs *errorCollector
+		roundAttempted := 0
+		var sampleResult testing.BenchmarkResult
+		if virtual || fixed {
+			var roundClock gameengine.Clock = wallClock{}
+			if virtual {
+				roundClock = clock
+			}
+			n := virtualClockIterations
+			if fixed {
+				n = iterations
+			}
+			var err error
+			sampleResult, sampleLatencies, roundErrs, err = runFixedRound(ctx, roundClock, n, entities, benchmarkFunc)
+			if err != nil && reseedErr == nil {
+				reseedErr = err
 			}
+			roundAttempted = sampleResult.N
+		} else {
+			sampleResult = testing.Benchmark(func(b *testing.B) {
+				// testing.Benchmark calls this closure several times while it calibrates b.N,
+				// so only the latencies, operations and errors of the last (measured) round are kept.
+				if roundErrs != nil {
+					calibration.attempted += roundAttempted
+					calibration.errors += roundErrs.Total()
+				}
+				roundErrs = newErrorCollector()
+				roundAttempted = 0
+				// Errors are kept raw while the timer runs and only classified once it is
+				// stopped, so the measured round does not pay for the collector.
+				roundErrors := make([]error, 0, b.N)
+				if err := reseedEngine(ctx); err != nil && reseedErr == nil {
+					reseedErr = err
+				}
+				entities.reset(b.N)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					err := benchmarkFunc()
+					roundAttempted++
+					if err != nil {
+						roundErrors = append(roundErrors, err)
+					}
+				}
+				b.StopTimer()
+				sampleLatencies = entities.measured()
+				for _, err := range roundErrors {
+					roundErrs.track(err)
+				}
+			})
 		}
-		b.StopTimer()
-	})
-
-	close(errorChannel)
-	var backgroundErrors []error
-	for e := range errorChannel {
-		backgroundErrors = append(backgroundErrors, e)
+		attempted += roundAttempted
+		processed += entities.processed
+		rounds = append(rounds, roundAttempted)
+		errs.merge(roundErrs)
+		latencies = append(latencies, sampleLatencies...)
+		samples = append(samples, float64(sampleResult.NsPerOp()))
+		benchmarkResult = mergeBenchmarkResults(benchmarkResult, sampleResult)
 	}
 
-	if overallErr != nil {
-		backgroundErrors = append([]error{fmt.Errorf("benchmark function structure error: %w", overallErr)}, backgroundErrors...)
+	if reseedErr != nil {
+		return ctx, benchmarkResult, errs, reseedErr
 	}
 	
-	c.Logf("%s Result	%s,%v,%v\n", c.Name(), c.Name(), benchmarkResult.N, benchmarkResult.NsPerOp())
+	c.Logf("%s Calibration	%d operations, %d errors (not counted)\n", c.Name(), calibration.attempted, calibration.errors)
+	c.Logf("%s Result	%s,%v,%v,%v,%v,%v\n", c.Name(), c.Name(), benchmarkResult.N, benchmarkResult.NsPerOp(),
+		benchmarkResult.AllocsPerOp(), benchmarkResult.AllocedBytesPerOp(), len(samples))
 	
 	ctx = context.WithValue(ctx, GodogsCtxBenchmarkResultKey, benchmarkResult)
-	if len(backgroundErrors) > 0 {
-		ctx = context.WithValue(ctx, GodogsCtxErrorKey, backgroundErrors) 
+	ctx = context.WithValue(ctx, GodogsCtxLatenciesKey, latencies)
+	ctx = context.WithValue(ctx, GodogsCtxSamplesKey, samples)
+	ctx = context.WithValue(ctx, GodogsCtxAttemptedKey, attempted)
+	ctx = context.WithValue(ctx, GodogsCtxProcessedKey, processed)
+	ctx = context.WithValue(ctx, GodogsCtxRoundsKey, rounds)
+	ctx = context.WithValue(ctx, GodogsCtxErrorKey, errs)
+	return ctx, benchmarkResult, errs, nil
+}
+
+// mergeBenchmarkResults sums two results so NsPerOp and friends average over every sample.
+func mergeBenchmarkResults(total, sample testing.BenchmarkResult) testing.BenchmarkResult {
+	total.N += sample.N
+	total.T += sample.T
+	total.Bytes += sample.Bytes
+	total.MemAllocs += sample.MemAllocs
+	total.MemBytes += sample.MemBytes
+	return total
+}
+
+// percentile returns the nearest-rank percentile p (0-100) of sorted latencies: the
+// smallest latency at least p percent of the latencies are less than or equal to.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
 	}
-	return ctx, benchmarkResult, backgroundErrors
+	return sorted[rank]
+}
+
+// latencyPercentile maps a step's statistic name (p50, p95, p99, max) onto the latencies.
+func latencyPercentile(latencies []time.Duration, statistic string) (time.Duration, error) {
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	switch statistic {
+	case "p50":
+		return percentile(sorted, 50), nil
+	case "p95":
+		return percentile(sorted, 95), nil
+	case "p99":
+		return percentile(sorted, 99), nil
+	case "max":
+		return percentile(sorted, 100), nil
+	}
+	return 0, fmt.Errorf("unknown latency statistic '%s'", statistic)
 }
 
 // createResultFile is a helper.
@@ -129,7 +244,7 @@ func RunCucumberSuite(t *testing.T, featureFilePath string, scenarioInitializer
 		Paths:          []string{featureFilePath},
 		TestingT:       t,
 		Strict:         true,
-		StopOnFailure:  true,
+		StopOnFailure:  !softAssertionsEnabled(),
 		DefaultContext: context.Background(), 
 	}
 
@@ -208,19 +323,39 @@ func getBenchmarkResultFromCtx(ctx context.Context) (testing.BenchmarkResult, er
 	return res, nil
 }
 
-func getErrorFromCtx(ctx context.Context) []error {
-	val := ctx.Value(GodogsCtxErrorKey)
+func getErrorFromCtx(ctx context.Context) *errorCollector {
+	errs, _ := ctx.Value(GodogsCtxErrorKey).(*errorCollector)
+	if errs == nil {
+		return newErrorCollector()
+	}
+	return errs
+}
+
+func getEngineFromCtx(ctx context.Context) (gameengine.Engine, error) {
+	val := ctx.Value(GodogsCtxEngineKey)
 	if val == nil {
-		return nil
+		return nil, fmt.Errorf("engine not found in context")
 	}
-	errs, ok := val.([]error)
+	engine, ok := val.(gameengine.Engine)
 	if !ok {
-		if singleErr, okSingle := val.(error); okSingle {
-			return []error{singleErr}
-		}
-		return []error{fmt.Errorf("errors in context are not of type []error or error: %T", val)}
+		return nil, fmt.Errorf("engine in context is not of type gameengine.Engine: %T", val)
 	}
-	return errs
+	return engine, nil
+}
+
+func getLatenciesFromCtx(ctx context.Context) ([]time.Duration, error) {
+	val := ctx.Value(GodogsCtxLatenciesKey)
+	if val == nil {
+		return nil, fmt.Errorf("latencies not found in context")
+	}
+	latencies, ok := val.([]time.Duration)
+	if !ok {
+		return nil, fmt.Errorf("latencies in context are not of type []time.Duration: %T", val)
+	}
+	if len(latencies) == 0 {
+		return nil, fmt.Errorf("no latencies were recorded")
+	}
+	return latencies, nil
 }
 
 func getIntFromCtx(ctx context.Context, key GodogsCtxKey) (int, error) {
@@ -250,7 +385,9 @@ func getStringFromCtx(ctx context.Context, key GodogsCtxKey) (string, error) {
 // Step Definition Functions
 
 func playerHasLevel(ctx context.Context, level int) (context.Context, error) {
-	return context.WithValue(ctx, GodogsCtxPlayerLevelKey, level), nil
+	profile, _ := getPlayerProfileFromCtx(ctx)
+	profile.Level = level
+	return context.WithValue(ctx, GodogsCtxPlayerProfileKey, profile), nil
 }
 
 func playerIsInArea(ctx context.Context, areaName string) (context.Context, error) {
@@ -258,225 +395,269 @@ func playerIsInArea(ctx context.Context, areaName string) (context.Context, erro
 }
 
 func playerIsMovingAtSpeed(ctx context.Context, speed string) (context.Context, error) {
-	return context.WithValue(ctx, "playerSpeed", speed), nil
+	unitsPerSecond, err := gameengine.ParseSpeed(speed)
+	if err != nil {
+		return ctx, err
+	}
+	profile, _ := getPlayerProfileFromCtx(ctx)
+	profile.Speed = unitsPerSecond
+	return context.WithValue(ctx, GodogsCtxPlayerProfileKey, profile), nil
 }
 
 // Modified aggregate function
-func aggregate(ctx context.Context, benchmarkResult testing.BenchmarkResult, backgroundErrors []error, targetCount int) (context.Context, error) {
+func aggregate(ctx context.Context, benchmarkResult testing.BenchmarkResult, errs *errorCollector, targetCount int) (context.Context, error) {
 	ctx = context.WithValue(ctx, GodogsCtxBenchmarkResultKey, benchmarkResult)
 	ctx = context.WithValue(ctx, GodogsCtxTargetCountKey, targetCount)
-
-	if len(backgroundErrors) > 0 {
-		ctx = context.WithValue(ctx, GodogsCtxErrorKey, backgroundErrors)
-		return ctx, backgroundErrors[0] 
-	}
+	ctx = context.WithValue(ctx, GodogsCtxErrorKey, errs)
+	// Operation errors do not fail the When step: they are judged by the Then steps,
+	// either as zero-error checks or as error rate budgets.
 	return ctx, nil
 }
 
-// Modified WhenXXX functions
-func playerFightsEnemiesMod(ctx context.Context, numEnemies int, gt godog.TestingT) (context.Context, error) {
-	if gt == nil { return ctx, fmt.Errorf("godog.TestingT not found in context for playerFightsEnemiesMod") }
-	c := NewTestAndBenchCommon(gt)
-	playerLevel, err := getIntFromCtx(ctx, GodogsCtxPlayerLevelKey)
+// Then step definitions
+// averageTimePerItemShouldBeLessThan checks the average time of a single item (enemy, guard, hit)
+// of the last measured operation.
+func averageTimePerItemShouldBeLessThan(ctx context.Context, item string, budget string) error {
+	expectedMaxPerItem, err := parseStepDuration(budget)
 	if err != nil {
-		return ctx, fmt.Errorf("player level not set: %w", err)
-	}
-	if numEnemies <= 0 { 
-		return ctx, fmt.Errorf("number of enemies must be positive, got %d", numEnemies)
-	}
-
-	errorChannel := makeErrorChannel(numEnemies + 10) // Buffer based on count
-
-	updatedCtx, br, bgErrs := RunAndReport(func(b *testing.B) error {
-		// The gameengine.FightEnemies is expected to use the passed 'b' for its N iterations.
-		gameEngineErr := gameengine.FightEnemies(b, numEnemies, playerLevel)
-		// This error is from the *entire* FightEnemies operation.
-		// If FightEnemies has errors per sub-op, it should use trackBenchmarkError.
-		if gameEngineErr != nil {
-		    trackBenchmarkError(b, gameEngineErr, errorChannel) 
-			// return gameEngineErr // This indicates a failure of the benchmarked unit itself
-		}
-		return nil // Assume errors within b.N are handled by trackBenchmarkError
-	}, errorChannel, c, ctx)
-	return aggregate(updatedCtx, br, bgErrs, numEnemies)
-}
-
-func guardsSpawnMod(ctx context.Context, numGuards int, gt godog.TestingT) (context.Context, error) {
-	if gt == nil { return ctx, fmt.Errorf("godog.TestingT not found in context for guardsSpawnMod") }
-	c := NewTestAndBenchCommon(gt)
-	if numGuards <= 0 {
-		return ctx, fmt.Errorf("number of guards must be positive, got %d", numGuards)
-	}
-	errorChannel := makeErrorChannel(numGuards + 10)
-
-	updatedCtx, br, bgErrs := RunAndReport(func(b *testing.B) error {
-		gameEngineErr := gameengine.SpawnGuards(b, numGuards)
-		if gameEngineErr != nil {
-			trackBenchmarkError(b, gameEngineErr, errorChannel)
-			// return gameEngineErr
-		}
-		return nil
-	}, errorChannel, c, ctx)
-	return aggregate(updatedCtx, br, bgErrs, numGuards)
-}
-
-func playerHitsWallMod(ctx context.Context, numHits int, gt godog.TestingT) (context.Context, error) {
-	if gt == nil { return ctx, fmt.Errorf("godog.TestingT not found in context for playerHitsWallMod") }
-	c := NewTestAndBenchCommon(gt)
-	if numHits <= 0 {
-		return ctx, fmt.Errorf("number of hits must be positive, got %d", numHits)
+		return err
 	}
-	errorChannel := makeErrorChannel(numHits + 10)
-
-	updatedCtx, br, bgErrs := RunAndReport(func(b *testing.B) error {
-		gameEngineErr := gameengine.HitWall(b, numHits)
-		if gameEngineErr != nil {
-			trackBenchmarkError(b, gameEngineErr, errorChannel)
-			// return gameEngineErr
-		}
-		return nil
-	}, errorChannel, c, ctx)
-	return aggregate(updatedCtx, br, bgErrs, numHits)
-}
-
-// Then step definitions
-func averageTimePerEnemyDefeatedShouldBeLessThan(ctx context.Context, expectedMsPerItem int) error {
 	benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
 	if err != nil {
 		return err
 	}
-	targetCount, err := getIntFromCtx(ctx, GodogsCtxTargetCountKey)
+	op, targetCount, err := measuredOperation(ctx, item)
 	if err != nil {
-		return fmt.Errorf("target count (numEnemies) not found in context for calculation: %w", err)
+		return err
 	}
-	if targetCount == 0 {
-		return fmt.Errorf("target count (numEnemies) is zero, cannot calculate per-item performance")
+	items, err := itemsPerOperation(ctx)
+	if err != nil {
+		return err
 	}
 
-	observedNsPerOp := benchmarkResult.NsPerOp() 
-	observedNsPerSingleItem := observedNsPerOp / int64(targetCount)
+	observedNsPerOp := benchmarkResult.NsPerOp()
+	observedNsPerSingleItem := int64(float64(observedNsPerOp) / items)
 	observedMsPerSingleItem := float64(observedNsPerSingleItem) / 1e6
 
-	expectedMaxNsPerItem := int64(expectedMsPerItem) * 1e6
+	expectedMaxNsPerItem := expectedMaxPerItem.Nanoseconds()
 
-	fmt.Printf("  Benchmark Metric: Average Time Per Enemy Defeated\n")
-	fmt.Printf("    Target Count in Operation: %d enemies\n", targetCount)
+	fmt.Printf("  Benchmark Metric: Average Time Per %s\n", op.Items[0])
+	fmt.Printf("    Target Count in Operation: %d\n", targetCount)
+	fmt.Printf("    Processed Per Operation: %.2f\n", items)
 	fmt.Printf("    Total NsPerOp (for group): %d ns\n", observedNsPerOp)
 	fmt.Printf("    Observed NsPerItem: %d ns (%.4f ms)\n", observedNsPerSingleItem, observedMsPerSingleItem)
-	fmt.Printf("    Expected Max NsPerItem: %d ns (%d ms)\n", expectedMaxNsPerItem, expectedMsPerItem)
-	// fmt.Printf("    Full Benchmark Result: %s\n", benchmarkResult.String())
-
+	fmt.Printf("    Expected Max NsPerItem: %d ns (%v)\n", expectedMaxNsPerItem, expectedMaxPerItem)
 
 	if observedNsPerSingleItem > expectedMaxNsPerItem {
-		return fmt.Errorf("expected average time per enemy to be less than %d ms (%.0fns), but was %.4f ms (%.0fns)",
-			expectedMsPerItem, float64(expectedMaxNsPerItem), observedMsPerSingleItem, float64(observedNsPerSingleItem))
+		return fmt.Errorf("expected average time per %s to be less than %v (%.0fns), but was %.4f ms (%.0fns)",
+			op.Items[0], expectedMaxPerItem, float64(expectedMaxNsPerItem), observedMsPerSingleItem, float64(observedNsPerSingleItem))
 	}
 	return nil
 }
 
-func playerReactsToAllGuardsWithinSeconds(ctx context.Context, expectedSeconds float64) error {
+// operationTimeShouldBeWithin checks the time of a whole operation, i.e. all items of one When step together.
+func operationTimeShouldBeWithin(ctx context.Context, operationName string, budget string) error {
+	expectedMax, err := parseStepDuration(budget)
+	if err != nil {
+		return err
+	}
+	expectedSeconds := expectedMax.Seconds()
 	benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
 	if err != nil {
 		return err
 	}
+	op, targetCount, err := measuredOperation(ctx, "")
+	if err != nil {
+		return err
+	}
+	if op.Name != operationName {
+		return fmt.Errorf("step asks about '%s' but the last measured operation was '%s'", operationName, op.Name)
+	}
 	observedNsPerOp := benchmarkResult.NsPerOp()
 	observedOpSeconds := float64(observedNsPerOp) / 1e9
-	
-	targetCount, _ := getIntFromCtx(ctx, GodogsCtxTargetCountKey) // For logging
 
-	fmt.Printf("  Benchmark Metric: Total Guard Spawning & Reaction Time\n")
-	fmt.Printf("    Target Count in Operation: %d guards\n", targetCount)
+	fmt.Printf("  Benchmark Metric: Total %s Time\n", op.Name)
+	fmt.Printf("    Target Count in Operation: %d\n", targetCount)
 	fmt.Printf("    Observed NsPerOp (total for operation): %d ns (%.4f s)\n", observedNsPerOp, observedOpSeconds)
 	fmt.Printf("    Expected Max Operation Time: %.2f s\n", expectedSeconds)
-	// fmt.Printf("    Full Benchmark Result: %s\n", benchmarkResult.String())
 
 	if observedOpSeconds > expectedSeconds {
-		return fmt.Errorf("expected reaction to all guards (spawning operation) to be within %.2f seconds, but was %.4f seconds",
-			expectedSeconds, observedOpSeconds)
+		return fmt.Errorf("expected the whole %s operation to be within %.2f seconds, but was %.4f seconds",
+			op.Name, expectedSeconds, observedOpSeconds)
 	}
 	return nil
 }
 
-func averageImpactProcessingTimeShouldBeLessThan(ctx context.Context, expectedMsPerItem int) error {
-	benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
+// percentileTimePerItemShouldBeLessThan checks a latency percentile of a single item
+// (enemy, guard, hit) over the entity latencies of the measured round.
+func percentileTimePerItemShouldBeLessThan(ctx context.Context, statistic string, item string, budget string) error {
+	expectedMaxPerItem, err := parseStepDuration(budget)
 	if err != nil {
 		return err
 	}
-	targetCount, err := getIntFromCtx(ctx, GodogsCtxTargetCountKey)
+	latencies, err := getLatenciesFromCtx(ctx)
 	if err != nil {
-		return fmt.Errorf("target count (numHits) not found in context for calculation: %w", err)
+		return err
 	}
-	if targetCount == 0 {
-		return fmt.Errorf("target count (numHits) is zero, cannot calculate per-item performance")
+	_, targetCount, err := measuredOperation(ctx, item)
+	if err != nil {
+		return err
 	}
 
-	observedNsPerOp := benchmarkResult.NsPerOp()
-	observedNsPerSingleItem := observedNsPerOp / int64(targetCount)
-	observedMsPerSingleItem := float64(observedNsPerSingleItem) / 1e6
+	observedPerItem, err := latencyPercentile(latencies, statistic)
+	if err != nil {
+		return err
+	}
 
-	expectedMaxNsPerItem := int64(expectedMsPerItem) * 1e6
+	fmt.Printf("  Benchmark Metric: %s Time Per %s\n", statistic, item)
+	fmt.Printf("    Target Count in Operation: %d\n", targetCount)
+	fmt.Printf("    Recorded %s: %d\n", item, len(latencies))
+	fmt.Printf("    Observed %s Per Item: %v\n", statistic, observedPerItem)
+	fmt.Printf("    Expected Max Per Item: %v\n", expectedMaxPerItem)
 
-	fmt.Printf("  Benchmark Metric: Average Impact Processing Time Per Hit\n")
-	fmt.Printf("    Target Count in Operation: %d hits\n", targetCount)
-	fmt.Printf("    Total NsPerOp (for group): %d ns\n", observedNsPerOp)
-	fmt.Printf("    Observed NsPerItem: %d ns (%.4f ms)\n", observedNsPerSingleItem, observedMsPerSingleItem)
-	fmt.Printf("    Expected Max NsPerItem: %d ns (%d ms)\n", expectedMaxNsPerItem, expectedMsPerItem)
-	// fmt.Printf("    Full Benchmark Result: %s\n", benchmarkResult.String())
+	if observedPerItem > expectedMaxPerItem {
+		return fmt.Errorf("expected %s time per %s to be less than %v, but was %v",
+			statistic, item, expectedMaxPerItem, observedPerItem)
+	}
+	return nil
+}
 
-	if observedNsPerSingleItem > expectedMaxNsPerItem {
-		return fmt.Errorf("expected average impact processing time per hit to be less than %d ms (%.0fns), but was %.4f ms (%.0fns)",
-			expectedMsPerItem, float64(expectedMaxNsPerItem), observedMsPerSingleItem, float64(observedNsPerSingleItem))
+// byteUnits maps the size units accepted in allocation steps onto bytes.
+var byteUnits = map[string]int64{
+	"B":  1,
+	"KB": 1024,
+	"MB": 1024 * 1024,
+}
+
+// allocationsPerItem returns the allocations and allocated bytes of one item (enemy, guard, hit).
+func allocationsPerItem(ctx context.Context, action string, item string) (float64, float64, int, error) {
+	benchmarkResult, err := getBenchmarkResultFromCtx(ctx)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	op, targetCount, err := measuredOperation(ctx, item)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if op.Action != action {
+		return 0, 0, 0, fmt.Errorf("step asks about '%s' but the last measured operation was '%s'", action, op.Name)
+	}
+	items, err := itemsPerOperation(ctx)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	allocsPerItem := float64(benchmarkResult.AllocsPerOp()) / items
+	bytesPerItem := float64(benchmarkResult.AllocedBytesPerOp()) / items
+	return allocsPerItem, bytesPerItem, targetCount, nil
+}
+
+func operationShouldAllocateAtMostObjectsPerItem(ctx context.Context, action string, maxAllocs int, item string) error {
+	allocsPerItem, _, targetCount, err := allocationsPerItem(ctx, action, item)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Benchmark Metric: Allocations Per %s\n", item)
+	fmt.Printf("    Target Count in Operation: %d\n", targetCount)
+	fmt.Printf("    Observed Allocs Per Item: %.4f\n", allocsPerItem)
+	fmt.Printf("    Expected Max Allocs Per Item: %d\n", maxAllocs)
+
+	if allocsPerItem > float64(maxAllocs) {
+		return fmt.Errorf("expected %s to allocate at most %d objects per %s, but allocated %.4f",
+			action, maxAllocs, item, allocsPerItem)
+	}
+	return nil
+}
+
+func operationShouldAllocateAtMostBytesPerItem(ctx context.Context, action string, maxSize int, unit string, item string) error {
+	_, bytesPerItem, targetCount, err := allocationsPerItem(ctx, action, item)
+	if err != nil {
+		return err
+	}
+	multiplier, ok := byteUnits[unit]
+	if !ok {
+		return fmt.Errorf("unknown size unit '%s'", unit)
+	}
+	maxBytes := int64(maxSize) * multiplier
+
+	fmt.Printf("  Benchmark Metric: Allocated Bytes Per %s\n", item)
+	fmt.Printf("    Target Count in Operation: %d\n", targetCount)
+	fmt.Printf("    Observed Bytes Per Item: %.1f B\n", bytesPerItem)
+	fmt.Printf("    Expected Max Bytes Per Item: %d B (%d %s)\n", maxBytes, maxSize, unit)
+
+	if bytesPerItem > float64(maxBytes) {
+		return fmt.Errorf("expected %s to allocate at most %d %s per %s, but allocated %.1f B",
+			action, maxSize, unit, item, bytesPerItem)
 	}
 	return nil
 }
 
 func allOperationsShouldCompleteWithoutError(ctx context.Context, operationType string) error {
-	errorsInCtx := getErrorFromCtx(ctx) 
-	if len(errorsInCtx) > 0 {
-		fmt.Printf("Found %d background error(s) for '%s' operations:\n", len(errorsInCtx), operationType)
-		for i, e := range errorsInCtx {
-			fmt.Printf("    Error %d: %v\n", i+1, e)
-		}
-		return fmt.Errorf("expected all '%s' operations to complete without error, but found %d errors. First error: %w", operationType, len(errorsInCtx), errorsInCtx[0])
+	if _, _, err := measuredOperation(ctx, ""); err != nil {
+		return err
+	}
+	if name, _ := getStringFromCtx(ctx, GodogsCtxOperationKey); name != operationType {
+		return fmt.Errorf("step asks about '%s' but the last measured operation was '%s'", operationType, name)
+	}
+	errorsInCtx := getErrorFromCtx(ctx)
+	if errorsInCtx.Total() > 0 {
+		printErrorReport(operationType, errorsInCtx)
+		return fmt.Errorf("expected all '%s' operations to complete without error, but found %d errors. First error: %w", operationType, errorsInCtx.Total(), errorsInCtx.Samples()[0])
 	}
 	return nil
 }
 
 // InitializeScenario binds step definitions.
 func InitializeScenario(scenarioCtx *godog.ScenarioContext) {
+	// Every scenario drives its own seeded engine so configuration never leaks between scenarios.
+	scenarioCtx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		return context.WithValue(ctx, GodogsCtxScenarioKey, sc), nil
+	})
+	// After hooks run in registration order and see the errors of the ones before them, so
+	// soft assertions are reported first and the seed hook can print the seed of their failure.
+	bindSoftAssertions(scenarioCtx)
+	bindSeed(scenarioCtx)
+
 	// Given steps
 	scenarioCtx.Step(`^the player has a level of (\d+)$`, playerHasLevel)
 	scenarioCtx.Step(`^the player is in the '([^']*)' area$`, playerIsInArea)
+	scenarioCtx.Step(`^the battlefield '([^']*)' is loaded$`, battlefieldIsLoaded)
 	scenarioCtx.Step(`^the player is moving at (\w+) speed$`, playerIsMovingAtSpeed)
+	scenarioCtx.Step(`^the player is moving at (\d+(?:\.\d+)? units per second)$`, playerIsMovingAtSpeed)
+	scenarioCtx.Step(`^the player wields an? (\w+)$`, playerWields)
+	scenarioCtx.Step(`^the benchmark is repeated (\d+) times?$`, benchmarkIsRepeated)
+	scenarioCtx.Step(`^the random seed is (-?\d+)$`, randomSeedIs)
+	scenarioCtx.Step(`^every benchmark round runs (\d+) operations?$`, everyRoundRunsOperations)
+	scenarioCtx.Step(`^the engine runs on a virtual clock$`, engineRunsOnVirtualClock)
+	scenarioCtx.Step(`^the engine simulates (CPU-bound|memory-bound|sleep|busy-spin|hashing|memory-bandwidth) work$`, engineSimulatesWork)
+	bindFaultSteps(scenarioCtx)
 
 	// When steps
-	scenarioCtx.Step(`^the player fights (\d+) enemies$`, func(sCtx context.Context, count int) (context.Context, error) {
-		godogT := godog.T(sCtx) // This retrieves godog.TestingT from the context
-		if godogT == nil { return sCtx, fmt.Errorf("godog.T(sCtx) returned nil for 'player fights enemies' step") }
-		// Removed duplicated declaration of godogT
-		return playerFightsEnemiesMod(sCtx, count, godogT)
-	})
-	scenarioCtx.Step(`^(\d+) guards spawn around the player$`, func(sCtx context.Context, count int) (context.Context, error) {
-		godogT := godog.T(sCtx)
-		if godogT == nil { return sCtx, fmt.Errorf("godog.T(sCtx) returned nil for 'guards spawn around' step") }
-		return guardsSpawnMod(sCtx, count, godogT)
-	})
-	scenarioCtx.Step(`^(\d+) guard spawns near the player$`, func(sCtx context.Context, count int) (context.Context, error) {
-		godogT := godog.T(sCtx)
-		if godogT == nil { return sCtx, fmt.Errorf("godog.T(sCtx) returned nil for 'guard spawns near' step") }
-		return guardsSpawnMod(sCtx, count, godogT)
-	})
-	scenarioCtx.Step(`^the player hits a wall (\d+) time(s)?$`, func(sCtx context.Context, count int) (context.Context, error) {
-		godogT := godog.T(sCtx)
-		if godogT == nil { return sCtx, fmt.Errorf("godog.T(sCtx) returned nil for 'player hits wall' step") }
-		return playerHitsWallMod(sCtx, count, godogT)
-	})
-
-	// Then steps
-	scenarioCtx.Step(`^the average time per enemy defeated should be less than (\d+) milliseconds$`, averageTimePerEnemyDefeatedShouldBeLessThan)
-	scenarioCtx.Step(`^the player reacts to all guards within (\d+) seconds$`, func(sCtx context.Context, seconds int) error {
-		return playerReactsToAllGuardsWithinSeconds(sCtx, float64(seconds))
-	})
-	scenarioCtx.Step(`^the average impact processing time should be less than (\d+) milliseconds$`, averageImpactProcessingTimeShouldBeLessThan)
-	scenarioCtx.Step(`^all (fight|guard spawning|hit wall) operations should complete without error$`, allOperationsShouldCompleteWithoutError)
+	operations.bindWhenSteps(scenarioCtx)
+
+	// Then steps report through softly so soft assertion mode can collect every failed check.
+	items, names, actions := operations.itemPattern(), operations.namePattern(), operations.actionPattern()
+	scenarioCtx.Step(`^the average time per `+items+` should be less than `+durationPattern+`$`, softly(averageTimePerItemShouldBeLessThan))
+	scenarioCtx.Step(`^the average impact processing time should be less than `+durationPattern+`$`, softly(func(sCtx context.Context, budget string) error {
+		return averageTimePerItemShouldBeLessThan(sCtx, "wall hit", budget)
+	}))
+	scenarioCtx.Step(`^the player reacts to all guards within `+durationPattern+`$`, softly(func(sCtx context.Context, budget string) error {
+		return operationTimeShouldBeWithin(sCtx, "guard spawning", budget)
+	}))
+	scenarioCtx.Step(`^the (p50|p95|p99|max) time per `+items+` should be less than `+durationPattern+`$`, softly(percentileTimePerItemShouldBeLessThan))
+	scenarioCtx.Step(`^`+actions+` should allocate at most (\d+) objects? per `+items+`$`, softly(operationShouldAllocateAtMostObjectsPerItem))
+	scenarioCtx.Step(`^`+actions+` should allocate at most (\d+) (B|KB|MB) per `+items+`$`, softly(operationShouldAllocateAtMostBytesPerItem))
+	scenarioCtx.Step(`^the average time per `+items+` should not regress by more than (\d+)% against the baseline$`, softly(averageTimePerItemShouldNotRegress))
+	scenarioCtx.Step(`^the mean time per `+items+` should be less than `+durationPattern+` with 95% confidence$`, softly(meanTimePerItemShouldBeLessThanWithConfidence))
+	scenarioCtx.Step(`^the time per `+items+` should not be significantly slower than the baseline$`, softly(timePerItemShouldNotBeSignificantlySlower))
+	scenarioCtx.Step(`^all `+names+` operations should complete without error$`, softly(allOperationsShouldCompleteWithoutError))
+	scenarioCtx.Step(`^all `+names+` operations should fail with ([a-z ]+?) errors$`, softly(allOperationsShouldFailWith))
+	scenarioCtx.Step(`^no ([a-z ]+?) errors should occur$`, softly(noErrorsOfKindShouldOccur))
+	scenarioCtx.Step(`^the `+names+` error rate should be below (\d+(?:\.\d+)?)%$`, softly(errorRateShouldBeBelow))
+	scenarioCtx.Step(`^the player should defeat all (\d+) enemies$`, softly(playerShouldDefeatAllEnemies))
+	scenarioCtx.Step(`^every enemy should charge in from a hostile base$`, softly(everyEnemyShouldChargeInFromAHostileBase))
+	scenarioCtx.Step(`^the player should bounce back$`, softly(playerShouldBounceBack))
+	scenarioCtx.Step(`^every wall hit should be resolved outside the walls$`, softly(everyWallHitShouldBeResolvedOutsideTheWalls))
+	scenarioCtx.Step(`^no (enemies|guards) should remain on the battlefield$`, softly(noEntitiesShouldRemain))
+	scenarioCtx.Step(`^the performance checklist should hold:$`, softly(performanceChecklistShouldHold))
 }
@@ -0,0 +1,23 @@
+package benchmarks
+
+import (
+	"context"
+
+	"dynasty-warriors-godog/gameengine"
+)
+
+// getPlayerProfileFromCtx returns the profile built by the Given steps so far, and whether any was.
+func getPlayerProfileFromCtx(ctx context.Context) (gameengine.PlayerProfile, bool) {
+	profile, ok := ctx.Value(GodogsCtxPlayerProfileKey).(gameengine.PlayerProfile)
+	return profile, ok
+}
+
+func playerWields(ctx context.Context, weaponName string) (context.Context, error) {
+	weapon, err := gameengine.ParseWeapon(weaponName)
+	if err != nil {
+		return ctx, err
+	}
+	profile, _ := getPlayerProfileFromCtx(ctx)
+	profile.Weapon = weapon
+	return context.WithValue(ctx, GodogsCtxPlayerProfileKey, profile), nil
+}
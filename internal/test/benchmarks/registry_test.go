@@ -0,0 +1,295 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"dynasty-warriors-godog/gameengine"
+
+	"github.com/cucumber/godog"
+)
+
+const (
+	// GodogsCtxOperationKey is the context key for the name of the benchmarkOperation measured last.
+	GodogsCtxOperationKey GodogsCtxKey = "operation"
+	// GodogsCtxEngineResultKey is the context key for the gameengine.Result of the last engine call.
+	GodogsCtxEngineResultKey GodogsCtxKey = "engineResult"
+)
+
+// engineCall performs one benchmark iteration against the engine.
+type engineCall func(ctx context.Context) (gameengine.Result, error)
+
+// benchmarkOperation describes a game mechanic the suite can benchmark. Registering one
+// gives it When steps, per-item timing, error tracking and every generic Then step.
+type benchmarkOperation struct {
+	// Name is used by steps such as "all fight operations should complete without error".
+	Name string
+	// Operation is the engine operation behind the benchmark.
+	Operation gameengine.Operation
+	// Events is the plural used by fault injection steps such as "2% of guard spawns fail".
+	Events string
+	// Action is the gerund used by steps such as "fighting should allocate at most 0 objects per enemy".
+	Action string
+	// Items are the nouns for a single processed entity, e.g. "enemy defeated" and "enemy".
+	// The first one is used in reports.
+	Items []string
+	// WhenSteps are the patterns running the operation, each capturing the count as (\d+).
+	WhenSteps []string
+	// Prepare validates the scenario state and returns the engine call for one benchmark
+	// iteration plus a description of the step parameters used to key baselines.
+	Prepare func(ctx context.Context, engine gameengine.Engine, count int) (engineCall, string, error)
+}
+
+// operationRegistry holds every benchmarkOperation known to the suite.
+type operationRegistry struct {
+	operations []*benchmarkOperation
+	byName     map[string]*benchmarkOperation
+	byItem     map[string]*benchmarkOperation
+	byAction   map[string]*benchmarkOperation
+	byEvents   map[string]*benchmarkOperation
+}
+
+func newOperationRegistry() *operationRegistry {
+	return &operationRegistry{
+		byName:   map[string]*benchmarkOperation{},
+		byItem:   map[string]*benchmarkOperation{},
+		byAction: map[string]*benchmarkOperation{},
+		byEvents: map[string]*benchmarkOperation{},
+	}
+}
+
+// register adds an operation, panicking on duplicate names, actions or items since
+// those would make the generated steps ambiguous.
+func (r *operationRegistry) register(op *benchmarkOperation) {
+	if _, exists := r.byName[op.Name]; exists {
+		panic(fmt.Sprintf("benchmark operation '%s' registered twice", op.Name))
+	}
+	if _, exists := r.byAction[op.Action]; exists {
+		panic(fmt.Sprintf("benchmark action '%s' registered twice", op.Action))
+	}
+	if _, exists := r.byEvents[op.Events]; exists {
+		panic(fmt.Sprintf("benchmark events '%s' registered twice", op.Events))
+	}
+	for _, item := range op.Items {
+		if _, exists := r.byItem[item]; exists {
+			panic(fmt.Sprintf("benchmark item '%s' registered twice", item))
+		}
+		r.byItem[item] = op
+	}
+	r.byName[op.Name] = op
+	r.byAction[op.Action] = op
+	r.byEvents[op.Events] = op
+	r.operations = append(r.operations, op)
+}
+
+// alternation builds a regex group such as (fight|guard spawning|hit wall).
+func alternation(values []string) string {
+	sorted := make([]string, len(values))
+	copy(sorted, values)
+	// Longer values first so "enemy defeated" is preferred over "enemy".
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	quoted := make([]string, len(sorted))
+	for i, v := range sorted {
+		quoted[i] = regexp.QuoteMeta(v)
+	}
+	return "(" + strings.Join(quoted, "|") + ")"
+}
+
+func (r *operationRegistry) namePattern() string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	return alternation(names)
+}
+
+func (r *operationRegistry) itemPattern() string {
+	items := make([]string, 0, len(r.byItem))
+	for item := range r.byItem {
+		items = append(items, item)
+	}
+	return alternation(items)
+}
+
+func (r *operationRegistry) actionPattern() string {
+	actions := make([]string, 0, len(r.byAction))
+	for action := range r.byAction {
+		actions = append(actions, action)
+	}
+	return alternation(actions)
+}
+
+func (r *operationRegistry) eventsPattern() string {
+	events := make([]string, 0, len(r.byEvents))
+	for e := range r.byEvents {
+		events = append(events, e)
+	}
+	return alternation(events)
+}
+
+// bindWhenSteps registers the When steps of every operation.
+func (r *operationRegistry) bindWhenSteps(scenarioCtx *godog.ScenarioContext) {
+	for _, op := range r.operations {
+		op := op
+		for _, pattern := range op.WhenSteps {
+			scenarioCtx.Step(pattern, func(sCtx context.Context, count int) (context.Context, error) {
+				godogT := godog.T(sCtx)
+				if godogT == nil {
+					return sCtx, fmt.Errorf("godog.T(sCtx) returned nil for '%s' step", op.Name)
+				}
+				return runOperation(sCtx, op, count, godogT)
+			})
+		}
+	}
+}
+
+// measuredOperation returns the operation measured by the last When step and its target
+// count, failing if a Then step talks about the items of a different operation.
+func measuredOperation(ctx context.Context, item string) (*benchmarkOperation, int, error) {
+	name, err := getStringFromCtx(ctx, GodogsCtxOperationKey)
+	if err != nil {
+		return nil, 0, fmt.Errorf("no operation has been measured yet: %w", err)
+	}
+	op, ok := operations.byName[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown benchmark operation '%s'", name)
+	}
+	if item != "" && operations.byItem[item] != op {
+		return nil, 0, fmt.Errorf("step asks about '%s' but the last measured operation was '%s'", item, op.Name)
+	}
+	targetCount, err := getIntFromCtx(ctx, GodogsCtxTargetCountKey)
+	if err != nil {
+		return nil, 0, fmt.Errorf("target count not found in context for calculation: %w", err)
+	}
+	if targetCount == 0 {
+		return nil, 0, fmt.Errorf("target count is zero, cannot calculate per-item performance")
+	}
+	return op, targetCount, nil
+}
+
+// itemsPerOperation returns how many items (enemies, guards, hits) an operation of the
+// measured rounds processed on average. Calls that stop early, because the player is
+// knocked out or a fault fires, process fewer than the target count, so per-item figures
+// divide by this instead.
+func itemsPerOperation(ctx context.Context) (float64, error) {
+	processed, err := getIntFromCtx(ctx, GodogsCtxProcessedKey)
+	if err != nil {
+		return 0, fmt.Errorf("processed items not found in context for calculation: %w", err)
+	}
+	attempted, err := getIntFromCtx(ctx, GodogsCtxAttemptedKey)
+	if err != nil {
+		return 0, err
+	}
+	if processed == 0 || attempted == 0 {
+		return 0, fmt.Errorf("no items were processed in the measured rounds, cannot calculate per-item performance")
+	}
+	return float64(processed) / float64(attempted), nil
+}
+
+// runOperation benchmarks count items of op and stores results, latencies and errors in the context.
+func runOperation(ctx context.Context, op *benchmarkOperation, count int, gt godog.TestingT) (context.Context, error) {
+	c := NewTestAndBenchCommon(gt)
+	if count <= 0 {
+		return ctx, fmt.Errorf("number of %s items must be positive, got %d", op.Name, count)
+	}
+	engine, err := getEngineFromCtx(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	call, parameters, err := op.Prepare(ctx, engine, count)
+	if err != nil {
+		return ctx, err
+	}
+	ctx, err = recordEntityLatencies(ctx, count)
+	if err != nil {
+		return ctx, err
+	}
+
+	var lastResult gameengine.Result
+	updatedCtx, br, errs, err := RunAndReport(func() error {
+		result, gameEngineErr := callRecoveringPanics(ctx, call)
+		lastResult = result
+		return gameEngineErr
+	}, newErrorCollector(), c, ctx)
+	if err != nil {
+		return ctx, err
+	}
+	updatedCtx = context.WithValue(updatedCtx, GodogsCtxOperationKey, op.Name)
+	updatedCtx = context.WithValue(updatedCtx, GodogsCtxEngineResultKey, lastResult)
+	attempted, err := getIntFromCtx(updatedCtx, GodogsCtxAttemptedKey)
+	if err != nil {
+		return updatedCtx, err
+	}
+	updatedCtx = recordOperationCounts(updatedCtx, op.Name, attempted, errs.Total())
+	updatedCtx, err = persistRun(updatedCtx, parameters, br, count)
+	if err != nil {
+		return updatedCtx, err
+	}
+	return aggregate(updatedCtx, br, errs, count)
+}
+
+// operations is the registry every scenario binds its steps from.
+var operations = newOperationRegistry()
+
+func init() {
+	operations.register(&benchmarkOperation{
+		Name:      string(gameengine.OperationFight),
+		Operation: gameengine.OperationFight,
+		Events:    "fights",
+		Action:    "fighting",
+		Items:     []string{"enemy defeated", "enemy"},
+		WhenSteps: []string{`^the player fights (\d+) enemies$`},
+		Prepare: func(ctx context.Context, engine gameengine.Engine, count int) (engineCall, string, error) {
+			profile, ok := getPlayerProfileFromCtx(ctx)
+			if !ok {
+				return nil, "", fmt.Errorf("player level not set")
+			}
+			opts := gameengine.FightOptions{Enemies: count, Player: profile}
+			call := func(ctx context.Context) (gameengine.Result, error) { return engine.FightEnemies(ctx, opts) }
+			parameters := fmt.Sprintf("fights %d enemies at level %d", count, profile.Level)
+			if profile.Weapon != "" {
+				parameters += fmt.Sprintf(" with a %s", profile.Weapon)
+			}
+			return call, parameters, nil
+		},
+	})
+	operations.register(&benchmarkOperation{
+		Name:      string(gameengine.OperationSpawnGuards),
+		Operation: gameengine.OperationSpawnGuards,
+		Events:    "guard spawns",
+		Action:    "spawning guards",
+		Items:     []string{"guard spawned", "guard"},
+		WhenSteps: []string{`^(\d+) guards spawn around the player$`, `^(\d+) guard spawns near the player$`},
+		Prepare: func(ctx context.Context, engine gameengine.Engine, count int) (engineCall, string, error) {
+			area, _ := getStringFromCtx(ctx, GodogsCtxAreaKey)
+			opts := gameengine.SpawnOptions{Guards: count, Area: area}
+			call := func(ctx context.Context) (gameengine.Result, error) { return engine.SpawnGuards(ctx, opts) }
+			parameters := fmt.Sprintf("spawns %d guards", count)
+			if area != "" {
+				parameters += fmt.Sprintf(" in %s", area)
+			}
+			return call, parameters, nil
+		},
+	})
+	operations.register(&benchmarkOperation{
+		Name:      string(gameengine.OperationHitWall),
+		Operation: gameengine.OperationHitWall,
+		Events:    "wall hits",
+		Action:    "hitting walls",
+		Items:     []string{"wall hit", "hit"},
+		WhenSteps: []string{`^the player hits a wall (\d+) times?$`},
+		Prepare: func(ctx context.Context, engine gameengine.Engine, count int) (engineCall, string, error) {
+			profile, _ := getPlayerProfileFromCtx(ctx)
+			opts := gameengine.HitWallOptions{Hits: count, Speed: profile.Speed}
+			call := func(ctx context.Context) (gameengine.Result, error) { return engine.HitWall(ctx, opts) }
+			parameters := fmt.Sprintf("hits a wall %d times", count)
+			if profile.Speed != 0 {
+				parameters += fmt.Sprintf(" at %g units per second", profile.Speed)
+			}
+			return call, parameters, nil
+		},
+	})
+}
@@ -0,0 +1,150 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"dynasty-warriors-godog/gameengine"
+
+	"github.com/cucumber/godog"
+)
+
+const (
+	// GodogsCtxSeedKey is the context key for the random seed of the scenario's engine.
+	GodogsCtxSeedKey GodogsCtxKey = "randomSeed"
+	// GodogsCtxIterationsKey is the context key for a fixed number of operations per benchmark round.
+	GodogsCtxIterationsKey GodogsCtxKey = "iterations"
+	// GodogsCtxRoundsKey is the context key for the operations of every measured round of the
+	// last When step. Together with the seed they decide which calls fail.
+	GodogsCtxRoundsKey GodogsCtxKey = "measuredRounds"
+)
+
+// defaultSeed returns BENCHMARK_SEED if set, so a whole run can be replayed, or a time based seed.
+func defaultSeed() (int64, error) {
+	if value, ok := os.LookupEnv("BENCHMARK_SEED"); ok {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("BENCHMARK_SEED must be an integer, got '%s'", value)
+		}
+		return seed, nil
+	}
+	return time.Now().UnixNano(), nil
+}
+
+func getSimulatorFromCtx(ctx context.Context) (*gameengine.Simulator, error) {
+	val := ctx.Value(GodogsCtxEngineKey)
+	if val == nil {
+		return nil, fmt.Errorf("engine not found in context")
+	}
+	simulator, ok := val.(*gameengine.Simulator)
+	if !ok {
+		return nil, fmt.Errorf("engine in context is not a *gameengine.Simulator and cannot be configured: %T", val)
+	}
+	return simulator, nil
+}
+
+func getSeedFromCtx(ctx context.Context) (int64, error) {
+	val := ctx.Value(GodogsCtxSeedKey)
+	if val == nil {
+		return 0, fmt.Errorf("random seed not found in context")
+	}
+	seed, ok := val.(int64)
+	if !ok {
+		return 0, fmt.Errorf("random seed in context is not of type int64: %T", val)
+	}
+	return seed, nil
+}
+
+// reseedEngine restarts the engine's random stream from the scenario seed. RunAndReport
+// calls it at the start of every benchmark round, so a round's failures only depend on
+// the seed and b.N.
+func reseedEngine(ctx context.Context) error {
+	seed, err := getSeedFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	simulator.Apply(gameengine.WithSeed(seed))
+	return nil
+}
+
+// getIterationsFromCtx returns the fixed number of operations per round set by the scenario
+// or BENCHMARK_ITERATIONS, and whether there is one. Without it testing.Benchmark calibrates b.N.
+func getIterationsFromCtx(ctx context.Context) (int, bool, error) {
+	if ctx.Value(GodogsCtxIterationsKey) != nil {
+		iterations, err := getIntFromCtx(ctx, GodogsCtxIterationsKey)
+		return iterations, err == nil, err
+	}
+	value, ok := os.LookupEnv("BENCHMARK_ITERATIONS")
+	if !ok {
+		return 0, false, nil
+	}
+	iterations, err := strconv.Atoi(value)
+	if err != nil || iterations <= 0 {
+		return 0, false, fmt.Errorf("BENCHMARK_ITERATIONS must be a positive integer, got '%s'", value)
+	}
+	return iterations, true, nil
+}
+
+func everyRoundRunsOperations(ctx context.Context, iterations int) (context.Context, error) {
+	if iterations <= 0 {
+		return ctx, fmt.Errorf("number of operations per round must be positive, got %d", iterations)
+	}
+	return context.WithValue(ctx, GodogsCtxIterationsKey, iterations), nil
+}
+
+func randomSeedIs(ctx context.Context, seed int64) (context.Context, error) {
+	ctx = context.WithValue(ctx, GodogsCtxSeedKey, seed)
+	return ctx, reseedEngine(ctx)
+}
+
+// bindSeed gives every scenario a seeded engine, on a virtual clock if BENCHMARK_VIRTUAL_CLOCK is set,
+// and prints the seed and round sizes of every failed scenario. Every round restarts the random
+// stream, so the calls failing in a round depend on both.
+func bindSeed(scenarioCtx *godog.ScenarioContext) {
+	scenarioCtx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		seed, err := defaultSeed()
+		if err != nil {
+			return ctx, err
+		}
+		ctx = context.WithValue(ctx, GodogsCtxSeedKey, seed)
+		opts := []gameengine.Option{gameengine.WithSeed(seed)}
+		if virtualClockEnabled() {
+			opts = append(opts, gameengine.WithClock(gameengine.NewVirtualClock(time.Time{})))
+		}
+		return context.WithValue(ctx, GodogsCtxEngineKey, gameengine.NewSimulator(opts...)), nil
+	})
+	scenarioCtx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		if err == nil {
+			return ctx, nil
+		}
+		seed, seedErr := getSeedFromCtx(ctx)
+		if seedErr != nil {
+			return ctx, nil
+		}
+		rounds, _ := ctx.Value(GodogsCtxRoundsKey).([]int)
+		if len(rounds) == 0 {
+			fmt.Printf("  Scenario '%s' failed with random seed %d (replay with 'Given the random seed is %d' or BENCHMARK_SEED=%d)\n",
+				sc.Name, seed, seed, seed)
+			return ctx, nil
+		}
+		fmt.Printf("  Scenario '%s' failed with random seed %d and %s operations per measured round (replay with 'Given the random seed is %d' and 'Given every benchmark round runs %d operations', or BENCHMARK_SEED=%d BENCHMARK_ITERATIONS=%d)\n",
+			sc.Name, seed, joinInts(rounds), seed, rounds[0], seed, rounds[0])
+		return ctx, nil
+	})
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,104 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/cucumber/godog"
+)
+
+// GodogsCtxSoftAssertionsKey is the context key for the *softAssertions of a scenario
+// running in soft assertion mode.
+const GodogsCtxSoftAssertionsKey GodogsCtxKey = "softAssertions"
+
+// softAssertionsTag enables soft assertions for a single scenario or feature.
+const softAssertionsTag = "@soft-assertions"
+
+// softAssertionsEnabled reports whether BENCHMARK_SOFT_ASSERTIONS turns soft assertions on for every scenario.
+func softAssertionsEnabled() bool {
+	_, ok := os.LookupEnv("BENCHMARK_SOFT_ASSERTIONS")
+	return ok
+}
+
+// softAssertions collects the failed Then steps of a scenario so they can be reported
+// together once the scenario is done instead of stopping at the first one.
+type softAssertions struct {
+	currentStep string
+	failures    []string
+}
+
+func (s *softAssertions) record(err error) {
+	s.failures = append(s.failures, fmt.Sprintf("Then %s: %v", s.currentStep, err))
+}
+
+// err aggregates every recorded failure into a single report, or returns nil.
+func (s *softAssertions) err() error {
+	if len(s.failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d performance check(s) failed:\n  - %s", len(s.failures), strings.Join(s.failures, "\n  - "))
+}
+
+func scenarioHasTag(sc *godog.Scenario, tag string) bool {
+	for _, t := range sc.Tags {
+		if t.Name == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// bindSoftAssertions installs the hooks creating, tracking and reporting soft assertions.
+func bindSoftAssertions(scenarioCtx *godog.ScenarioContext) {
+	scenarioCtx.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+		if softAssertionsEnabled() || scenarioHasTag(sc, softAssertionsTag) {
+			ctx = context.WithValue(ctx, GodogsCtxSoftAssertionsKey, &softAssertions{})
+		}
+		return ctx, nil
+	})
+	scenarioCtx.StepContext().Before(func(ctx context.Context, st *godog.Step) (context.Context, error) {
+		if soft, ok := ctx.Value(GodogsCtxSoftAssertionsKey).(*softAssertions); ok {
+			soft.currentStep = st.Text
+		}
+		return ctx, nil
+	})
+	scenarioCtx.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		if soft, ok := ctx.Value(GodogsCtxSoftAssertionsKey).(*softAssertions); ok {
+			return ctx, soft.err()
+		}
+		return ctx, nil
+	})
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// softly wraps a func(context.Context, ...) error Then step so that, in soft assertion
+// mode, its failure is recorded and the scenario carries on with the next check.
+func softly(handler interface{}) interface{} {
+	v := reflect.ValueOf(handler)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() == 0 || t.In(0) != contextType || t.NumOut() != 1 || t.Out(0) != errorType {
+		panic(fmt.Sprintf("softly needs a func(context.Context, ...) error step handler, got %s", t))
+	}
+	return reflect.MakeFunc(t, func(args []reflect.Value) []reflect.Value {
+		out := v.Call(args)
+		if out[0].IsNil() {
+			return out
+		}
+		ctx := args[0].Interface().(context.Context)
+		soft, ok := ctx.Value(GodogsCtxSoftAssertionsKey).(*softAssertions)
+		if !ok {
+			return out
+		}
+		err := out[0].Interface().(error)
+		fmt.Printf("    Soft assertion failed, continuing: %v\n", err)
+		soft.record(err)
+		return []reflect.Value{reflect.Zero(errorType)}
+	}).Interface()
+}
@@ -0,0 +1,363 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	// GodogsCtxRepetitionsKey is the context key for how many benchmark samples a When step collects.
+	GodogsCtxRepetitionsKey GodogsCtxKey = "repetitions"
+	// GodogsCtxSamplesKey is the context key for the ns/op of every collected sample.
+	GodogsCtxSamplesKey GodogsCtxKey = "samples"
+)
+
+// significanceLevel is the alpha below which a Mann-Whitney U test counts as a real difference,
+// the same default benchstat uses.
+const significanceLevel = 0.05
+
+// sampleStats summarizes a set of samples the way benchstat does.
+type sampleStats struct {
+	N      int
+	Mean   float64
+	StdDev float64
+	// CILow and CIHigh bound the 95% confidence interval of the mean.
+	CILow  float64
+	CIHigh float64
+}
+
+// tQuantile975 holds the two-sided 95% Student's t quantiles for 1 to 30 degrees of freedom.
+var tQuantile975 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func computeSampleStats(samples []float64) sampleStats {
+	stats := sampleStats{N: len(samples)}
+	if stats.N == 0 {
+		return stats
+	}
+	for _, s := range samples {
+		stats.Mean += s
+	}
+	stats.Mean /= float64(stats.N)
+	stats.CILow, stats.CIHigh = stats.Mean, stats.Mean
+	if stats.N < 2 {
+		return stats
+	}
+
+	var sumSquares float64
+	for _, s := range samples {
+		sumSquares += (s - stats.Mean) * (s - stats.Mean)
+	}
+	stats.StdDev = math.Sqrt(sumSquares / float64(stats.N-1))
+
+	t := 1.96
+	if df := stats.N - 1; df <= len(tQuantile975) {
+		t = tQuantile975[df-1]
+	}
+	margin := t * stats.StdDev / math.Sqrt(float64(stats.N))
+	stats.CILow, stats.CIHigh = stats.Mean-margin, stats.Mean+margin
+	return stats
+}
+
+// mannWhitneyU runs a two-sided Mann-Whitney U test and returns U for a and the p-value.
+// It uses the normal approximation with tie and continuity correction.
+func mannWhitneyU(a, b []float64) (float64, float64) {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	type rankedValue struct {
+		value float64
+		fromA bool
+	}
+	values := make([]rankedValue, 0, n1+n2)
+	for _, v := range a {
+		values = append(values, rankedValue{v, true})
+	}
+	for _, v := range b {
+		values = append(values, rankedValue{v, false})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].value < values[j].value })
+
+	// Tied values share the average of their ranks.
+	var rankSumA, tieCorrection float64
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].value == values[i].value {
+			j++
+		}
+		averageRank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if values[k].fromA {
+				rankSumA += averageRank
+			}
+		}
+		ties := float64(j - i)
+		tieCorrection += ties*ties*ties - ties
+		i = j
+	}
+
+	n := float64(n1 + n2)
+	u := rankSumA - float64(n1*(n1+1))/2
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieCorrection/(n*(n-1))))
+	if sigma == 0 {
+		return u, 1
+	}
+	z := (math.Abs(u-mu) - 0.5) / sigma
+	if z < 0 {
+		z = 0
+	}
+	return u, math.Erfc(z / math.Sqrt2)
+}
+
+// smallestPValue returns the smallest p-value mannWhitneyU can report for n1 against n2
+// samples, reached when the two do not overlap at all. If it is not below significanceLevel,
+// no slowdown can ever count as significant.
+func smallestPValue(n1, n2 int) float64 {
+	a := make([]float64, n1)
+	b := make([]float64, n2)
+	for i := range a {
+		a[i] = float64(i)
+	}
+	for i := range b {
+		b[i] = float64(n1 + i)
+	}
+	_, p := mannWhitneyU(a, b)
+	return p
+}
+
+// perItemSamples converts ns/op samples into ns per item (enemy, guard, hit), given the
+// items an operation processed on average.
+func perItemSamples(samples []float64, items float64) []float64 {
+	perItem := make([]float64, len(samples))
+	for i, s := range samples {
+		perItem[i] = s / items
+	}
+	return perItem
+}
+
+func getRepetitionsFromCtx(ctx context.Context) (int, error) {
+	if ctx.Value(GodogsCtxRepetitionsKey) != nil {
+		return getIntFromCtx(ctx, GodogsCtxRepetitionsKey)
+	}
+	count, ok := os.LookupEnv("BENCHMARK_COUNT")
+	if !ok {
+		return 1, nil
+	}
+	repetitions, err := strconv.Atoi(count)
+	if err != nil || repetitions <= 0 {
+		return 0, fmt.Errorf("BENCHMARK_COUNT must be a positive integer, got '%s'", count)
+	}
+	return repetitions, nil
+}
+
+func getSamplesFromCtx(ctx context.Context) ([]float64, error) {
+	val := ctx.Value(GodogsCtxSamplesKey)
+	if val == nil {
+		return nil, fmt.Errorf("samples not found in context")
+	}
+	samples, ok := val.([]float64)
+	if !ok {
+		return nil, fmt.Errorf("samples in context are not of type []float64: %T", val)
+	}
+	return samples, nil
+}
+
+func benchmarkIsRepeated(ctx context.Context, repetitions int) (context.Context, error) {
+	if repetitions <= 0 {
+		return ctx, fmt.Errorf("number of repetitions must be positive, got %d", repetitions)
+	}
+	return context.WithValue(ctx, GodogsCtxRepetitionsKey, repetitions), nil
+}
+
+func printSampleStats(label string, stats sampleStats) {
+	relativeCI := 0.0
+	if stats.Mean != 0 {
+		relativeCI = (stats.CIHigh - stats.Mean) / stats.Mean * 100
+	}
+	fmt.Printf("    %s: mean %v ± %.1f%% (stddev %v, 95%% CI %v..%v, n=%d)\n", label,
+		time.Duration(stats.Mean), relativeCI, time.Duration(stats.StdDev),
+		time.Duration(stats.CILow), time.Duration(stats.CIHigh), stats.N)
+}
+
+// meanTimePerItemShouldBeLessThanWithConfidence passes only if the whole 95% confidence
+// interval of the mean lies below the budget, so one lucky sample cannot hide a slow run.
+func meanTimePerItemShouldBeLessThanWithConfidence(ctx context.Context, item string, budget string) error {
+	expectedMaxPerItem, err := parseStepDuration(budget)
+	if err != nil {
+		return err
+	}
+	samples, err := getSamplesFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if _, _, err := measuredOperation(ctx, item); err != nil {
+		return err
+	}
+	items, err := itemsPerOperation(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats := computeSampleStats(perItemSamples(samples, items))
+
+	fmt.Printf("  Benchmark Metric: Mean Time Per %s With 95%% Confidence\n", item)
+	printSampleStats("Observed", stats)
+	fmt.Printf("    Expected Max Per Item: %v\n", expectedMaxPerItem)
+
+	if stats.CIHigh > float64(expectedMaxPerItem) {
+		return fmt.Errorf("expected mean time per %s to be less than %v with 95%% confidence, but the confidence interval reaches %v (n=%d)",
+			item, expectedMaxPerItem, time.Duration(stats.CIHigh), stats.N)
+	}
+	return nil
+}
+
+// timePerItemShouldNotBeSignificantlySlower compares the samples against the baseline samples
+// with a Mann-Whitney U test and fails only on a statistically significant slowdown.
+func timePerItemShouldNotBeSignificantlySlower(ctx context.Context, item string) error {
+	samples, err := getSamplesFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if _, _, err := measuredOperation(ctx, item); err != nil {
+		return err
+	}
+	items, err := itemsPerOperation(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("  Benchmark Metric: Significance Of Time Per %s Against Baseline\n", item)
+	baseline, _ := ctx.Value(GodogsCtxBaselineKey).(*baselineRecord)
+	if baseline == nil || len(baseline.Samples) == 0 {
+		fmt.Printf("    No previous baseline samples to compare against (baselines are kept in BENCHMARK_RESULTS_DIR)\n")
+		return nil
+	}
+
+	if p := smallestPValue(len(samples), len(baseline.Samples)); p >= significanceLevel {
+		return fmt.Errorf("%d samples against %d baseline samples can never differ significantly (smallest possible p=%.3f, alpha %.2f); repeat the benchmark more often and update the baseline",
+			len(samples), len(baseline.Samples), p, significanceLevel)
+	}
+
+	current := perItemSamples(samples, items)
+	currentStats := computeSampleStats(current)
+	baselineStats := computeSampleStats(baseline.Samples)
+	_, p := mannWhitneyU(current, baseline.Samples)
+
+	printSampleStats("Baseline", baselineStats)
+	printSampleStats("Observed", currentStats)
+	fmt.Printf("    Mann-Whitney U: p=%.3f (alpha %.2f)\n", p, significanceLevel)
+
+	if p < significanceLevel && currentStats.Mean > baselineStats.Mean {
+		return fmt.Errorf("expected time per %s not to be significantly slower than the baseline, but mean went from %v to %v (p=%.3f)",
+			item, time.Duration(baselineStats.Mean), time.Duration(currentStats.Mean), p)
+	}
+	return nil
+}
+
+func TestComputeSampleStats(t *testing.T) {
+	alternating := make([]float64, 40)
+	for i := range alternating {
+		alternating[i] = float64(9 + 2*(i%2))
+	}
+	tests := []struct {
+		name    string
+		samples []float64
+		want    sampleStats
+	}{
+		{name: "no samples", want: sampleStats{}},
+		{name: "one sample has no spread", samples: []float64{5}, want: sampleStats{N: 1, Mean: 5, CILow: 5, CIHigh: 5}},
+		{
+			name:    "two samples use the t quantile of one degree of freedom",
+			samples: []float64{1, 3},
+			want:    sampleStats{N: 2, Mean: 2, StdDev: 1.4142135623730951, CILow: -10.706, CIHigh: 14.706},
+		},
+		{
+			name:    "five samples use the t quantile of four degrees of freedom",
+			samples: []float64{1, 2, 3, 4, 5},
+			want:    sampleStats{N: 5, Mean: 3, StdDev: 1.5811388300841898, CILow: 3 - 1.9629284245738559, CIHigh: 3 + 1.9629284245738559},
+		},
+		{
+			name:    "more than 31 samples use the normal quantile",
+			samples: alternating,
+			want:    sampleStats{N: 40, Mean: 10, StdDev: 1.0127393670836666, CILow: 10 - 0.31385118145797075, CIHigh: 10 + 0.31385118145797075},
+		},
+	}
+	const epsilon = 1e-9
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeSampleStats(tt.samples)
+			if got.N != tt.want.N ||
+				math.Abs(got.Mean-tt.want.Mean) > epsilon ||
+				math.Abs(got.StdDev-tt.want.StdDev) > epsilon ||
+				math.Abs(got.CILow-tt.want.CILow) > epsilon ||
+				math.Abs(got.CIHigh-tt.want.CIHigh) > epsilon {
+				t.Errorf("computeSampleStats(%v) = %+v, want %+v", tt.samples, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		u, p float64
+	}{
+		{name: "no samples", a: nil, b: []float64{1, 2}, u: 0, p: 1},
+		{name: "all values tied", a: []float64{5, 5}, b: []float64{5, 5}, u: 2, p: 1},
+		{name: "fully separated", a: []float64{1, 2, 3}, b: []float64{4, 5, 6}, u: 0, p: 0.0808555983700523},
+		{name: "fully separated the other way", a: []float64{4, 5, 6}, b: []float64{1, 2, 3}, u: 9, p: 0.0808555983700523},
+		{name: "interleaved", a: []float64{1, 3, 5}, b: []float64{2, 4, 6}, u: 3, p: 0.6625205835400575},
+		{name: "ties share their average rank", a: []float64{1, 2, 2, 3}, b: []float64{2, 3, 3, 4}, u: 3, p: 0.17203370892182296},
+		{
+			name: "ten clearly slower samples are significant",
+			a:    []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			b:    []float64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+			u:    0,
+			p:    0.0001826717911095504,
+		},
+	}
+	const epsilon = 1e-9
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, p := mannWhitneyU(tt.a, tt.b)
+			if math.Abs(u-tt.u) > epsilon || math.Abs(p-tt.p) > epsilon {
+				t.Errorf("mannWhitneyU(%v, %v) = (%v, %v), want (%v, %v)", tt.a, tt.b, u, p, tt.u, tt.p)
+			}
+		})
+	}
+}
+
+func TestSmallestPValue(t *testing.T) {
+	tests := []struct {
+		n1, n2      int
+		significant bool
+	}{
+		{n1: 3, n2: 3, significant: false},
+		{n1: 3, n2: 4, significant: false},
+		{n1: 4, n2: 4, significant: true},
+		{n1: 5, n2: 3, significant: true},
+		{n1: 2, n2: 30, significant: true},
+		{n1: 1, n2: 30, significant: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d against %d", tt.n1, tt.n2), func(t *testing.T) {
+			p := smallestPValue(tt.n1, tt.n2)
+			if significant := p < significanceLevel; significant != tt.significant {
+				t.Errorf("smallestPValue(%d, %d) = %.4f, significant = %v, want %v", tt.n1, tt.n2, p, significant, tt.significant)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package benchmarks
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationPattern captures a threshold in step text. It accepts Go duration literals
+// ("250µs", "1m30s") as well as a number followed by a unit word ("1.5 ms", "2 seconds", "1 second").
+const durationPattern = `((?:\d+(?:\.\d+)?(?:ns|us|µs|μs|ms|s|m|h))+|\d+(?:\.\d+)?\s*(?:ns|us|µs|μs|ms|s|m|h|nanoseconds?|microseconds?|milliseconds?|seconds?|minutes?|hours?))`
+
+// durationUnitWords maps unit words used in step text onto durations.
+var durationUnitWords = map[string]time.Duration{
+	"ns":          time.Nanosecond,
+	"nanosecond":  time.Nanosecond,
+	"us":          time.Microsecond,
+	"µs":          time.Microsecond,
+	"μs":          time.Microsecond,
+	"microsecond": time.Microsecond,
+	"ms":          time.Millisecond,
+	"millisecond": time.Millisecond,
+	"s":           time.Second,
+	"second":      time.Second,
+	"m":           time.Minute,
+	"minute":      time.Minute,
+	"h":           time.Hour,
+	"hour":        time.Hour,
+}
+
+var numberAndUnitWord = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-zµμ]+)$`)
+
+// parseStepDuration parses a threshold captured by durationPattern.
+func parseStepDuration(text string) (time.Duration, error) {
+	text = strings.TrimSpace(text)
+	if d, err := time.ParseDuration(text); err == nil {
+		return d, nil
+	}
+	match := numberAndUnitWord.FindStringSubmatch(text)
+	if match == nil {
+		return 0, fmt.Errorf("'%s' is not a duration", text)
+	}
+	unit, ok := durationUnitWords[match[2]]
+	if !ok {
+		// Plural unit words, e.g. "seconds".
+		unit, ok = durationUnitWords[strings.TrimSuffix(match[2], "s")]
+	}
+	if !ok {
+		return 0, fmt.Errorf("'%s' has an unknown duration unit", text)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' has an invalid number: %w", text, err)
+	}
+	return time.Duration(value * float64(unit)), nil
+}
@@ -0,0 +1,31 @@
+package benchmarks
+
+import (
+	"context"
+
+	"dynasty-warriors-godog/gameengine"
+)
+
+// workModelAliases maps the words used in step text onto the engine's work models.
+var workModelAliases = map[string]gameengine.WorkModel{
+	"CPU-bound":    gameengine.WorkHashing,
+	"memory-bound": gameengine.WorkMemoryBandwidth,
+}
+
+// engineSimulatesWork selects the work model of the scenario's engine, e.g.
+// "the engine simulates CPU-bound work" or "the engine simulates busy-spin work".
+func engineSimulatesWork(ctx context.Context, name string) (context.Context, error) {
+	model, ok := workModelAliases[name]
+	if !ok {
+		var err error
+		if model, err = gameengine.ParseWorkModel(name); err != nil {
+			return ctx, err
+		}
+	}
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	simulator.Apply(gameengine.WithWorkModel(model))
+	return ctx, nil
+}
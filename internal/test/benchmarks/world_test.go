@@ -0,0 +1,206 @@
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dynasty-warriors-godog/gameengine"
+)
+
+// noEntitiesShouldRemain checks that the engine destroyed every enemy or guard it created.
+func noEntitiesShouldRemain(ctx context.Context, kind string) error {
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	world := simulator.World()
+	remaining := len(world.Enemies())
+	if kind == "guards" {
+		remaining = len(world.Guards())
+	}
+
+	fmt.Printf("  Benchmark Metric: Remaining %s\n", kind)
+	fmt.Printf("    Observed: %d\n", remaining)
+
+	if remaining > 0 {
+		return fmt.Errorf("expected no %s to remain on the battlefield, but found %d", kind, remaining)
+	}
+	return nil
+}
+
+func getEngineResultFromCtx(ctx context.Context) (gameengine.Result, error) {
+	val := ctx.Value(GodogsCtxEngineResultKey)
+	if val == nil {
+		return gameengine.Result{}, fmt.Errorf("no engine result found in context")
+	}
+	result, ok := val.(gameengine.Result)
+	if !ok {
+		return gameengine.Result{}, fmt.Errorf("engine result in context is not of type gameengine.Result: %T", val)
+	}
+	return result, nil
+}
+
+// playerShouldDefeatAllEnemies checks the gameplay outcome of the last fight.
+func playerShouldDefeatAllEnemies(ctx context.Context, enemies int) error {
+	op, _, err := measuredOperation(ctx, "enemy")
+	if err != nil {
+		return err
+	}
+	result, err := getEngineResultFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if result.Operation != op.Operation {
+		return fmt.Errorf("the last engine call was '%s', not a fight", result.Operation)
+	}
+	combat := result.Combat
+
+	fmt.Printf("  Benchmark Metric: Combat Outcome Of The Last Fight\n")
+	fmt.Printf("    KOs: %d of %d\n", combat.KOs, enemies)
+	fmt.Printf("    Attacks: %d (%d critical)\n", combat.Attacks, combat.CriticalHits)
+	fmt.Printf("    Damage Dealt: %d\n", combat.DamageDealt)
+	fmt.Printf("    Damage Taken: %d\n", combat.DamageTaken)
+
+	if combat.KOs != enemies {
+		return fmt.Errorf("expected the player to defeat all %d enemies, but only %d were defeated", enemies, combat.KOs)
+	}
+	return nil
+}
+
+// everyEnemyShouldChargeInFromAHostileBase checks that the enemies of the last fight came
+// from the bases of the loaded battlefield rather than from around the player.
+func everyEnemyShouldChargeInFromAHostileBase(ctx context.Context) error {
+	op, _, err := measuredOperation(ctx, "enemy")
+	if err != nil {
+		return err
+	}
+	result, err := getEngineResultFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if result.Operation != op.Operation {
+		return fmt.Errorf("the last engine call was '%s', not a fight", result.Operation)
+	}
+
+	fmt.Printf("  Benchmark Metric: Enemies Charging In From Bases\n")
+	fmt.Printf("    Observed: %d of %d enemies defeated\n", result.Combat.FromBases, result.Count)
+
+	if result.Count == 0 || result.Combat.FromBases < result.Count {
+		return fmt.Errorf("expected every enemy to charge in from a hostile base, but %d of %d did", result.Combat.FromBases, result.Count)
+	}
+	return nil
+}
+
+// insideAWall reports whether the player's collision circle at pos overlaps any of walls.
+func insideAWall(pos gameengine.Vec2, walls []gameengine.AABB) bool {
+	body := gameengine.Circle{Center: pos, Radius: gameengine.PlayerRadius}
+	for _, wall := range walls {
+		if _, overlapping := gameengine.CircleAABB(body, wall); overlapping {
+			return true
+		}
+	}
+	return false
+}
+
+// lastWallHits returns the result of the last HitWall call and its target count.
+func lastWallHits(ctx context.Context) (gameengine.Result, int, error) {
+	op, targetCount, err := measuredOperation(ctx, "wall hit")
+	if err != nil {
+		return gameengine.Result{}, 0, err
+	}
+	result, err := getEngineResultFromCtx(ctx)
+	if err != nil {
+		return gameengine.Result{}, 0, err
+	}
+	if result.Operation != op.Operation {
+		return gameengine.Result{}, 0, fmt.Errorf("the last engine call was '%s', not a wall hit", result.Operation)
+	}
+	return result, targetCount, nil
+}
+
+// everyWallHitShouldBeResolvedOutsideTheWalls checks the collision outcome of the last
+// HitWall call: every hit made contact and left the player clear of every wall, both
+// at the contact and where the knockback stopped, and still on the battlefield.
+func everyWallHitShouldBeResolvedOutsideTheWalls(ctx context.Context) error {
+	result, targetCount, err := lastWallHits(ctx)
+	if err != nil {
+		return err
+	}
+	simulator, err := getSimulatorFromCtx(ctx)
+	if err != nil {
+		return err
+	}
+	walls := simulator.World().Walls()
+	bounds := simulator.World().Bounds()
+
+	stuck, outside := 0, 0
+	for _, impact := range result.Impacts {
+		if insideAWall(impact.Resolved, walls) || insideAWall(impact.Rest, walls) {
+			stuck++
+		}
+		if !insideBounds(impact.Rest, bounds) {
+			outside++
+		}
+	}
+
+	fmt.Printf("  Benchmark Metric: Collision Outcome Of The Last Wall Hits\n")
+	fmt.Printf("    Contacts: %d of %d hits\n", len(result.Impacts), targetCount)
+	fmt.Printf("    Collision Steps: %d (%.1f per hit)\n", result.Steps, float64(result.Steps)/float64(targetCount))
+	fmt.Printf("    Resolved Inside A Wall: %d\n", stuck)
+	fmt.Printf("    Resting Outside The Battlefield: %d\n", outside)
+
+	if len(result.Impacts) != targetCount {
+		return fmt.Errorf("expected all %d wall hits to make contact, but %d did", targetCount, len(result.Impacts))
+	}
+	if stuck > 0 {
+		return fmt.Errorf("expected every wall hit to be resolved outside the walls, but %d were resolved inside one", stuck)
+	}
+	if outside > 0 {
+		return fmt.Errorf("expected every wall hit to leave the player on the battlefield %v, but %d came to rest outside it", bounds, outside)
+	}
+	return nil
+}
+
+// insideBounds reports whether pos lies within bounds.
+func insideBounds(pos gameengine.Vec2, bounds gameengine.AABB) bool {
+	return pos.X >= bounds.Min.X && pos.X <= bounds.Max.X && pos.Y >= bounds.Min.Y && pos.Y <= bounds.Max.Y
+}
+
+// playerShouldBounceBack checks the physics response of the last HitWall call: after every
+// hit the player moved away from the wall it touched.
+func playerShouldBounceBack(ctx context.Context) error {
+	result, targetCount, err := lastWallHits(ctx)
+	if err != nil {
+		return err
+	}
+
+	stuck := 0
+	var totalKnockback float64
+	var maxStagger time.Duration
+	for _, impact := range result.Impacts {
+		if impact.Outgoing.Dot(impact.Normal) <= 0 || impact.Rest.Sub(impact.Resolved).Dot(impact.Normal) <= 0 {
+			stuck++
+		}
+		totalKnockback += impact.Knockback
+		if impact.Stagger > maxStagger {
+			maxStagger = impact.Stagger
+		}
+	}
+
+	fmt.Printf("  Benchmark Metric: Physics Response Of The Last Wall Hits\n")
+	fmt.Printf("    Impacts: %d of %d hits\n", len(result.Impacts), targetCount)
+	if len(result.Impacts) > 0 {
+		fmt.Printf("    Average Knockback: %.2f units\n", totalKnockback/float64(len(result.Impacts)))
+	}
+	fmt.Printf("    Longest Stagger: %v\n", maxStagger)
+	fmt.Printf("    Impacts Without Bounce: %d\n", stuck)
+
+	if len(result.Impacts) != targetCount {
+		return fmt.Errorf("expected all %d wall hits to make contact, but %d did", targetCount, len(result.Impacts))
+	}
+	if stuck > 0 {
+		return fmt.Errorf("expected the player to bounce back from every wall hit, but %d of %d hits did not", stuck, targetCount)
+	}
+	return nil
+}